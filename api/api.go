@@ -1,4 +1,16 @@
 // Package api provides functionality that matches the Cisco Spark API.
 // The Cisco Spark API is documented at
 // https://developer.ciscospark.com/resource-people.html
+//
+// List methods return a util.Pager, which retrieves the results page by page
+// as far as the given util.Paging asks for.
+//
+// Update methods send an unexported body (e.g. roomUpdate) with only the
+// fields the service lets you change.  Those fields have no omitempty, so
+// flags can be set to false and lists emptied.  The service replaces all of
+// them, so callers start from the result of Get and change only what's
+// needed.
+//
+// Every method takes a context.Context; cancelling it stops the request in
+// progress, including the pages a util.Pager retrieves later on.
 package api
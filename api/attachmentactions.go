@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+)
+
+type AttachmentActionService struct {
+	Client *util.Client
+}
+
+// AttachmentAction is created when someone submits an Adaptive Card.  Inputs
+// holds the values of the card's input fields.
+type AttachmentAction struct {
+	Id        string                 `json:"id,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	MessageId string                 `json:"messageId,omitempty"`
+	Inputs    map[string]interface{} `json:"inputs,omitempty"`
+	PersonId  string                 `json:"personId,omitempty"`
+	RoomId    string                 `json:"roomId,omitempty"`
+	Created   string                 `json:"created,omitempty"`
+}
+
+func (a AttachmentActionService) Get(ctx context.Context, id string) (*AttachmentAction, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting an attachment action")
+	}
+	req, err := a.Client.NewGetRequest(ctx, "/attachment/actions/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result AttachmentAction
+	_, err = a.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+	"strconv"
+)
+
+type EventService struct {
+	Client *util.Client
+}
+
+// Event is a change to a resource (e.g. a message that was deleted), as
+// recorded for compliance.  Data holds the resource as it was at the time of
+// the event.
+type Event struct {
+	Id       string          `json:"id,omitempty"`
+	Resource string          `json:"resource,omitempty"`
+	Type     string          `json:"type,omitempty"`
+	AppId    string          `json:"appId,omitempty"`
+	ActorId  string          `json:"actorId,omitempty"`
+	OrgId    string          `json:"orgId,omitempty"`
+	Created  string          `json:"created,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// EventFilter limits the events returned by List.  All fields are optional.
+// From and To are ISO 8601 timestamps.
+type EventFilter struct {
+	Resource string // messages, memberships, ...
+	Type     string // created, updated or deleted
+	ActorId  string
+	From     string
+	To       string
+	Max      int // events per page
+}
+
+func (e EventService) List(ctx context.Context, filter EventFilter, paging util.Paging) (*util.Pager, error) {
+	v := url.Values{}
+	if filter.Resource != "" {
+		v.Add("resource", filter.Resource)
+	}
+	if filter.Type != "" {
+		v.Add("type", filter.Type)
+	}
+	if filter.ActorId != "" {
+		v.Add("actorId", filter.ActorId)
+	}
+	if filter.From != "" {
+		v.Add("from", filter.From)
+	}
+	if filter.To != "" {
+		v.Add("to", filter.To)
+	}
+	if filter.Max > 0 {
+		v.Add("max", strconv.Itoa(filter.Max))
+	}
+	path := "/events"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	req, err := e.Client.NewGetRequest(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return e.Client.NewPager(req, paging), nil
+}
+
+func (e EventService) Get(ctx context.Context, id string) (*Event, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting an event")
+	}
+	req, err := e.Client.NewGetRequest(ctx, "/events/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Event
+	_, err = e.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
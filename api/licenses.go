@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+)
+
+type LicenseService struct {
+	Client *util.Client
+}
+
+type License struct {
+	Id            string `json:"id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	TotalUnits    int    `json:"totalUnits,omitempty"`
+	ConsumedUnits int    `json:"consumedUnits,omitempty"`
+}
+
+// List the licenses of an organization.  If orgId is empty, the licenses of
+// your own organization are returned.
+func (l LicenseService) List(ctx context.Context, orgId string, paging util.Paging) (*util.Pager, error) {
+	path := "/licenses"
+	if orgId != "" {
+		v := url.Values{}
+		v.Add("orgId", orgId)
+		path += "?" + v.Encode()
+	}
+	req, err := l.Client.NewGetRequest(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return l.Client.NewPager(req, paging), nil
+}
+
+func (l LicenseService) Get(ctx context.Context, id string) (*License, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a license")
+	}
+	req, err := l.Client.NewGetRequest(ctx, "/licenses/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result License
+	_, err = l.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
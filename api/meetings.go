@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+	"strconv"
+)
+
+type MeetingService struct {
+	Client *util.Client
+}
+
+// Meeting is a scheduled meeting.  Start and End are ISO 8601 times,
+// interpreted in Timezone when they don't include an offset.
+type Meeting struct {
+	Id            string    `json:"id,omitempty"`
+	Title         string    `json:"title,omitempty"`
+	Agenda        string    `json:"agenda,omitempty"`
+	Password      string    `json:"password,omitempty"`
+	Start         string    `json:"start,omitempty"`
+	End           string    `json:"end,omitempty"`
+	Timezone      string    `json:"timezone,omitempty"`
+	Invitees      []Invitee `json:"invitees,omitempty"` // only used on create
+	HostEmail     string    `json:"hostEmail,omitempty"`
+	MeetingNumber string    `json:"meetingNumber,omitempty"`
+	WebLink       string    `json:"webLink,omitempty"`
+	SipAddress    string    `json:"sipAddress,omitempty"`
+	State         string    `json:"state,omitempty"`
+}
+
+// meetingUpdate is the body for a meeting update.
+type meetingUpdate struct {
+	Title    string `json:"title"`
+	Agenda   string `json:"agenda"`
+	Password string `json:"password"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type Invitee struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// List scheduled meetings.  from and to (ISO 8601) limit the time range and
+// max the number of meetings per page; all are optional.
+func (m MeetingService) List(ctx context.Context, from string, to string, max int, paging util.Paging) (*util.Pager, error) {
+	v := url.Values{}
+	if from != "" {
+		v.Add("from", from)
+	}
+	if to != "" {
+		v.Add("to", to)
+	}
+	if max > 0 {
+		v.Add("max", strconv.Itoa(max))
+	}
+	path := "/meetings"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	req, err := m.Client.NewGetRequest(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return m.Client.NewPager(req, paging), nil
+}
+
+// Create schedules a meeting.  Title, Start and End are required.
+func (m MeetingService) Create(ctx context.Context, meeting Meeting) (*Meeting, error) {
+	if meeting.Title == "" || meeting.Start == "" || meeting.End == "" {
+		return nil, errors.New("title, start and end are required when creating a meeting")
+	}
+	req, err := m.Client.NewPostRequest(ctx, "/meetings", meeting)
+	if err != nil {
+		return nil, err
+	}
+	var result Meeting
+	_, err = m.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (m MeetingService) Get(ctx context.Context, id string) (*Meeting, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a meeting")
+	}
+	req, err := m.Client.NewGetRequest(ctx, "/meetings/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Meeting
+	_, err = m.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Update the title, agenda, password, start, end and timezone of a meeting.
+// The service requires title, start, end and password.  Invitees can't be
+// changed this way.
+func (m MeetingService) Update(ctx context.Context, id string, meeting Meeting) (*Meeting, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a meeting")
+	}
+	update := meetingUpdate{
+		Title:    meeting.Title,
+		Agenda:   meeting.Agenda,
+		Password: meeting.Password,
+		Start:    meeting.Start,
+		End:      meeting.End,
+		Timezone: meeting.Timezone,
+	}
+	req, err := m.Client.NewPutRequest(ctx, "/meetings/"+id, update)
+	if err != nil {
+		return nil, err
+	}
+	var result Meeting
+	_, err = m.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (m MeetingService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a meeting")
+	}
+	req, err := m.Client.NewDeleteRequest(ctx, "/meetings/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = m.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
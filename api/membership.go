@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"github.com/tdeckers/sparkcli/util"
 	"net/url"
@@ -18,14 +19,17 @@ type Membership struct {
 	PersonDisplayName string `json:"personDisplayName,omitempty"`
 	IsModerator       bool   `json:"isModerator,omitempty"`
 	IsMonitor         bool   `json:"isMonitor,omitempty"`
+	IsRoomHidden      bool   `json:"isRoomHidden,omitempty"`
 	Created           string `json:"created,omitempty"`
 }
 
-type MembershipItems struct {
-	Items []Membership `json:"items"`
+// membershipUpdate is the body for a membership update.
+type membershipUpdate struct {
+	IsModerator  bool `json:"isModerator"`
+	IsRoomHidden bool `json:"isRoomHidden"`
 }
 
-func (m MemberService) List(roomId string, personId string, personEmail string) (*[]Membership, error) {
+func (m MemberService) List(ctx context.Context, roomId string, personId string, personEmail string, paging util.Paging) (*util.Pager, error) {
 	v := url.Values{}
 	if roomId != "" {
 		v.Add("roomId", roomId)
@@ -36,19 +40,14 @@ func (m MemberService) List(roomId string, personId string, personEmail string)
 	if personEmail != "" {
 		v.Add("personEmail", personEmail)
 	}
-	req, err := m.Client.NewGetRequest("/memberships?" + v.Encode())
+	req, err := m.Client.NewGetRequest(ctx, "/memberships?"+v.Encode())
 	if err != nil {
 		return nil, err
 	}
-	var result MembershipItems
-	_, err = m.Client.Do(req, &result)
-	if err != nil {
-		return nil, err
-	}
-	return &result.Items, nil
+	return m.Client.NewPager(req, paging), nil
 }
 
-func (m MemberService) Create(roomId, personId, personEmail string) (*Membership, error) {
+func (m MemberService) Create(ctx context.Context, roomId, personId, personEmail string) (*Membership, error) {
 	// check default room id
 	config := util.GetConfiguration()
 	if roomId == "-" {
@@ -59,7 +58,7 @@ func (m MemberService) Create(roomId, personId, personEmail string) (*Membership
 		}
 	}
 	ms := Membership{RoomId: roomId, PersonId: personId, PersonEmail: personEmail}
-	req, err := m.Client.NewPostRequest("/memberships", ms)
+	req, err := m.Client.NewPostRequest(ctx, "/memberships", ms)
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +70,8 @@ func (m MemberService) Create(roomId, personId, personEmail string) (*Membership
 	return &result, nil
 }
 
-func (m MemberService) Get(id string) (*Membership, error) {
-	req, err := m.Client.NewGetRequest("/memberships/" + id)
+func (m MemberService) Get(ctx context.Context, id string) (*Membership, error) {
+	req, err := m.Client.NewGetRequest(ctx, "/memberships/"+id)
 	if err != nil {
 		return nil, err
 	}
@@ -84,9 +83,11 @@ func (m MemberService) Get(id string) (*Membership, error) {
 	return &result, nil
 }
 
-func (m MemberService) Update(id string, isModerator bool) (*Membership, error) {
-	ms := Membership{IsModerator: isModerator}
-	req, err := m.Client.NewPutRequest("/memberships/"+id, ms)
+// Update the mutable fields of a membership: the moderator role and whether
+// the room is hidden from the person's room list.
+func (m MemberService) Update(ctx context.Context, id string, isModerator bool, isRoomHidden bool) (*Membership, error) {
+	ms := membershipUpdate{IsModerator: isModerator, IsRoomHidden: isRoomHidden}
+	req, err := m.Client.NewPutRequest(ctx, "/memberships/"+id, ms)
 	if err != nil {
 		return nil, err
 	}
@@ -98,8 +99,8 @@ func (m MemberService) Update(id string, isModerator bool) (*Membership, error)
 	return &result, nil
 }
 
-func (m MemberService) Delete(id string) error {
-	req, err := m.Client.NewDeleteRequest("/memberships/" + id)
+func (m MemberService) Delete(ctx context.Context, id string) error {
+	req, err := m.Client.NewDeleteRequest(ctx, "/memberships/"+id)
 	if err != nil {
 		return err
 	}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/tdeckers/sparkcli/util"
-	"log"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 type MessageService struct {
@@ -11,53 +15,209 @@ type MessageService struct {
 }
 
 type Message struct {
-	Id            string `json:"id,omitempty"`
-	RoomId        string `json:"roomId,omitempty"`
-	Text          string `json:"text,omitempty"`
-	Files         string `json:"files,omitempty"`
-	ToPersonId    string `json:"toPersonId,omitempty"`
-	ToPersonEmail string `json:"toPersonEmail,omitempty"`
-	PersonId      string `json:"personId,omitempty"`
-	PersonEmail   string `json:"personEmail,omitempty"`
-	Created       string `json:"created,omitempty"`
+	Id            string       `json:"id,omitempty"`
+	RoomId        string       `json:"roomId,omitempty"`
+	ParentId      string       `json:"parentId,omitempty"`
+	Text          string       `json:"text,omitempty"`
+	Markdown      string       `json:"markdown,omitempty"`
+	Html          string       `json:"html,omitempty"` // read-only, rendered from Markdown
+	Files         []string     `json:"files,omitempty"`
+	Attachments   []Attachment `json:"attachments,omitempty"`
+	ToPersonId    string       `json:"toPersonId,omitempty"`
+	ToPersonEmail string       `json:"toPersonEmail,omitempty"`
+	PersonId      string       `json:"personId,omitempty"`
+	PersonEmail   string       `json:"personEmail,omitempty"`
+	Created       string       `json:"created,omitempty"`
 }
 
-type MessageItems struct {
-	Items []Message `json:"items"`
+// Attachment is a rich attachment on a message, such as an Adaptive Card.
+type Attachment struct {
+	ContentType string          `json:"contentType"`
+	Content     json.RawMessage `json:"content"`
 }
 
-func (m MessageService) list() (*[]Message, error) {
-	log.Fatal("Not implemented")
-	return nil, nil
+const cardContentType = "application/vnd.microsoft.card.adaptive"
+
+// MessageFilter limits the messages returned by List.  All fields are
+// optional.
+type MessageFilter struct {
+	ParentId        string // only replies to this message
+	MentionedPeople string // only messages mentioning this person id, or "me"
+	Before          string // only messages sent before this ISO 8601 time
+	BeforeMessage   string // only messages sent before this message id
+	Max             int    // messages per page
+}
+
+// List the messages in a room, newest first.
+func (m MessageService) List(ctx context.Context, roomId string, filter MessageFilter, paging util.Paging) (*util.Pager, error) {
+	v := url.Values{}
+	v.Add("roomId", roomId)
+	if filter.ParentId != "" {
+		v.Add("parentId", filter.ParentId)
+	}
+	if filter.MentionedPeople != "" {
+		v.Add("mentionedPeople", filter.MentionedPeople)
+	}
+	if filter.Before != "" {
+		v.Add("before", filter.Before)
+	}
+	if filter.BeforeMessage != "" {
+		v.Add("beforeMessage", filter.BeforeMessage)
+	}
+	if filter.Max > 0 {
+		v.Add("max", strconv.Itoa(filter.Max))
+	}
+	req, err := m.Client.NewGetRequest(ctx, "/messages?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return m.Client.NewPager(req, paging), nil
+}
+
+// Create a text message in a room.  If parentId is not empty, the message is
+// posted as a reply in the thread of that message.
+func (m MessageService) Create(ctx context.Context, roomId string, parentId string, txt string) (*Message, error) {
+	return m.create(ctx, Message{RoomId: roomId, ParentId: parentId, Text: txt})
+}
+
+// CreateMarkdown creates a message with a markdown body in a room.  The
+// service renders the markdown into the message Html.
+func (m MessageService) CreateMarkdown(ctx context.Context, roomId string, parentId string, markdown string) (*Message, error) {
+	if markdown == "" {
+		return nil, errors.New("markdown can't be empty when creating a message")
+	}
+	return m.create(ctx, Message{RoomId: roomId, ParentId: parentId, Markdown: markdown})
+}
+
+// CreateCard posts an Adaptive Card in a room.  card is the card JSON, which
+// is validated with ParseCard first.  fallbackText is shown by clients that
+// can't render cards.
+func (m MessageService) CreateCard(ctx context.Context, roomId string, card []byte, fallbackText string) (*Message, error) {
+	content, err := ParseCard(card)
+	if err != nil {
+		return nil, err
+	}
+	msg := Message{
+		RoomId:      roomId,
+		Text:        fallbackText,
+		Attachments: []Attachment{{ContentType: cardContentType, Content: content}},
+	}
+	return m.create(ctx, msg)
 }
 
-func (m MessageService) List(roomId string) (*[]Message, error) {
-	req, err := m.Client.NewGetRequest("/messages?roomId=" + roomId)
+// ParseCard checks that card is a JSON object describing an Adaptive Card,
+// with its type and version set, and returns it for use as attachment content.
+func ParseCard(card []byte) (json.RawMessage, error) {
+	var fields struct {
+		Type    string      `json:"type"`
+		Version interface{} `json:"version"`
+	}
+	if err := json.Unmarshal(card, &fields); err != nil {
+		return nil, errors.New("Invalid card JSON: " + err.Error())
+	}
+	if fields.Type != "AdaptiveCard" {
+		return nil, errors.New("Invalid card: type should be AdaptiveCard")
+	}
+	if fields.Version == nil {
+		return nil, errors.New("Invalid card: version is missing")
+	}
+	return json.RawMessage(card), nil
+}
+
+// CreateDirect sends a text message straight to a person (1:1).  person is
+// either an email address or a person id.
+func (m MessageService) CreateDirect(ctx context.Context, person string, txt string) (*Message, error) {
+	msg, err := directMessage(person)
+	if err != nil {
+		return nil, err
+	}
+	msg.Text = txt
+	return m.create(ctx, msg)
+}
+
+// CreateDirectMarkdown sends a markdown message straight to a person (1:1).
+// person is either an email address or a person id.
+func (m MessageService) CreateDirectMarkdown(ctx context.Context, person string, markdown string) (*Message, error) {
+	if markdown == "" {
+		return nil, errors.New("markdown can't be empty when creating a message")
+	}
+	msg, err := directMessage(person)
+	if err != nil {
+		return nil, err
+	}
+	msg.Markdown = markdown
+	return m.create(ctx, msg)
+}
+
+// CreateDirectFile sends a file straight to a person (1:1).  person is either
+// an email address or a person id.
+func (m MessageService) CreateDirectFile(ctx context.Context, person string, file string) (*Message, error) {
+	msg, err := directMessage(person)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	if msg.ToPersonEmail != "" {
+		fields["toPersonEmail"] = msg.ToPersonEmail
+	} else {
+		fields["toPersonId"] = msg.ToPersonId
+	}
+	req, err := m.Client.NewFileUploadRequest(ctx, "/messages", fields, file)
 	if err != nil {
 		return nil, err
 	}
-	var result MessageItems
+	var result Message
 	_, err = m.Client.Do(req, &result)
 	if err != nil {
 		return nil, err
 	}
-	return &result.Items, nil
+	return &result, nil
 }
 
-// TODO: create different version, or update, to support direct msgs.
-func (m MessageService) Create(roomId string, txt string) (*Message, error) {
+// ListDirect lists the messages of the 1:1 conversation with a person.
+// person is either an email address or a person id.
+func (m MessageService) ListDirect(ctx context.Context, person string, paging util.Paging) (*util.Pager, error) {
+	msg, err := directMessage(person)
+	if err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	if msg.ToPersonEmail != "" {
+		v.Add("personEmail", msg.ToPersonEmail)
+	} else {
+		v.Add("personId", msg.ToPersonId)
+	}
+	req, err := m.Client.NewGetRequest(ctx, "/messages/direct?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return m.Client.NewPager(req, paging), nil
+}
+
+// directMessage returns a Message addressed to person, by email if person
+// looks like an email address and by person id otherwise.
+func directMessage(person string) (Message, error) {
+	if person == "" {
+		return Message{}, errors.New("person can't be empty for a direct message")
+	}
+	if strings.Contains(person, "@") {
+		return Message{ToPersonEmail: person}, nil
+	}
+	return Message{ToPersonId: person}, nil
+}
+
+func (m MessageService) create(ctx context.Context, msg Message) (*Message, error) {
 	// Check for default roomId
 	config := util.GetConfiguration()
-	if roomId == "-" {
+	if msg.RoomId == "-" {
 		if config.DefaultRoomId != "" {
-			roomId = config.DefaultRoomId
+			msg.RoomId = config.DefaultRoomId
 		} else {
 			return nil, errors.New("No DefaultRoomId configured.")
 		}
 	}
 
-	msg := Message{RoomId: roomId, Text: txt}
-	req, err := m.Client.NewPostRequest("/messages", msg)
+	req, err := m.Client.NewPostRequest(ctx, "/messages", msg)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +229,7 @@ func (m MessageService) Create(roomId string, txt string) (*Message, error) {
 	return &result, nil
 }
 
-func (m MessageService) CreateFile(roomId string, file string) (*Message, error) {
+func (m MessageService) CreateFile(ctx context.Context, roomId string, file string) (*Message, error) {
 	// Check for default roomId
 	config := util.GetConfiguration()
 	if roomId == "-" {
@@ -80,7 +240,41 @@ func (m MessageService) CreateFile(roomId string, file string) (*Message, error)
 		}
 	}
 
-	req, err := m.Client.NewFilePostRequest("/messages", roomId, file)
+	req, err := m.Client.NewFilePostRequest(ctx, "/messages", roomId, file)
+	if err != nil {
+		return nil, err
+	}
+	var result Message
+	_, err = m.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Update replaces the text of a message that was sent before.  The API needs
+// the roomId of the message along with the new text.
+func (m MessageService) Update(ctx context.Context, id string, roomId string, txt string) (*Message, error) {
+	return m.update(ctx, id, Message{RoomId: roomId, Text: txt})
+}
+
+// UpdateMarkdown replaces the body of a message that was sent before with
+// markdown.
+func (m MessageService) UpdateMarkdown(ctx context.Context, id string, roomId string, markdown string) (*Message, error) {
+	return m.update(ctx, id, Message{RoomId: roomId, Markdown: markdown})
+}
+
+func (m MessageService) update(ctx context.Context, id string, msg Message) (*Message, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a message")
+	}
+	if msg.RoomId == "" {
+		return nil, errors.New("roomId can't be empty when updating a message")
+	}
+	if msg.Text == "" && msg.Markdown == "" {
+		return nil, errors.New("text or markdown should be specified when updating a message")
+	}
+	req, err := m.Client.NewPutRequest(ctx, "/messages/"+id, msg)
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +286,11 @@ func (m MessageService) CreateFile(roomId string, file string) (*Message, error)
 	return &result, nil
 }
 
-func (m MessageService) Get(id string) (*Message, error) {
+func (m MessageService) Get(ctx context.Context, id string) (*Message, error) {
 	if id == "" {
 		return nil, errors.New("id can't be empty when getting message")
 	}
-	req, err := m.Client.NewGetRequest("/messages/" + id)
+	req, err := m.Client.NewGetRequest(ctx, "/messages/"+id)
 	if err != nil {
 		return nil, err
 	}
@@ -108,11 +302,29 @@ func (m MessageService) Get(id string) (*Message, error) {
 	return &result, nil
 }
 
-func (m MessageService) Delete(id string) error {
+// DownloadFiles saves the attachments of msg in dir and returns the paths of
+// the saved files.
+func (m MessageService) DownloadFiles(ctx context.Context, msg *Message, dir string) ([]string, error) {
+	var paths []string
+	for _, contentUrl := range msg.Files {
+		req, err := m.Client.NewContentRequest(ctx, contentUrl)
+		if err != nil {
+			return paths, err
+		}
+		path, err := m.Client.Download(req, dir)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (m MessageService) Delete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id can't be empty when deleting a message")
 	}
-	req, err := m.Client.NewDeleteRequest("/messages/" + id)
+	req, err := m.Client.NewDeleteRequest(ctx, "/messages/"+id)
 	if err != nil {
 		return err
 	}
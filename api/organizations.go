@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+)
+
+type OrganizationService struct {
+	Client *util.Client
+}
+
+type Organization struct {
+	Id          string `json:"id,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	Created     string `json:"created,omitempty"`
+}
+
+func (o OrganizationService) List(ctx context.Context, paging util.Paging) (*util.Pager, error) {
+	req, err := o.Client.NewGetRequest(ctx, "/organizations")
+	if err != nil {
+		return nil, err
+	}
+	return o.Client.NewPager(req, paging), nil
+}
+
+func (o OrganizationService) Get(ctx context.Context, id string) (*Organization, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting an organization")
+	}
+	req, err := o.Client.NewGetRequest(ctx, "/organizations/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Organization
+	_, err = o.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"github.com/tdeckers/sparkcli/util"
 	"net/url"
+	"strings"
 )
 
 type PeopleService struct {
@@ -11,19 +13,40 @@ type PeopleService struct {
 }
 
 type People struct {
-	Id          string   `json:"id,omitempty"`
-	Emails      []string `json:"emails,omitempty"`
-	DisplayName string   `json:"displayName,omitempty"`
-	Avatar      string   `json:"avatar,omitempty"`
-	Created     string   `json:"created,omitempty"`
+	Id           string   `json:"id,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	DisplayName  string   `json:"displayName,omitempty"`
+	NickName     string   `json:"nickName,omitempty"`
+	FirstName    string   `json:"firstName,omitempty"`
+	LastName     string   `json:"lastName,omitempty"`
+	Avatar       string   `json:"avatar,omitempty"`
+	OrgId        string   `json:"orgId,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+	Licenses     []string `json:"licenses,omitempty"`
+	Created      string   `json:"created,omitempty"`
+	LastActivity string   `json:"lastActivity,omitempty"`
+	Status       string   `json:"status,omitempty"`
+	Type         string   `json:"type,omitempty"`
 }
 
-type PeopleItems struct {
-	Items []People `json:"items"`
+// personUpdate is the body for a person update.
+type personUpdate struct {
+	Emails      []string `json:"emails"`
+	DisplayName string   `json:"displayName,omitempty"`
+	NickName    string   `json:"nickName,omitempty"`
+	FirstName   string   `json:"firstName,omitempty"`
+	LastName    string   `json:"lastName,omitempty"`
+	Avatar      string   `json:"avatar,omitempty"`
+	OrgId       string   `json:"orgId,omitempty"`
+	Roles       []string `json:"roles"`
+	Licenses    []string `json:"licenses"`
 }
 
-func (p PeopleService) List(email string, displayName string) (*[]People, error) {
-	if email == "" && displayName == "" {
+// List people by email, displayName (startsWith) or a list of ids.  orgId
+// limits the search to an organization; admins can list all people of their
+// organization with only orgId.
+func (p PeopleService) List(ctx context.Context, email string, displayName string, ids []string, orgId string, paging util.Paging) (*util.Pager, error) {
+	if email == "" && displayName == "" && len(ids) == 0 && orgId == "" {
 		// TODO: don't need to create this message.  Just return what service returns.
 		//{
 		//	"message": "Email or displayName should be specified.",
@@ -34,7 +57,7 @@ func (p PeopleService) List(email string, displayName string) (*[]People, error)
 		//	],
 		//	"trackingId": "NA_4de291c7-f857-4c3b-a02d-5129e7cea02c"
 		//}
-		return nil, errors.New("Email or displayName should be specified")
+		return nil, errors.New("Email, displayName, id or orgId should be specified")
 	}
 	v := url.Values{}
 	if email != "" {
@@ -44,20 +67,78 @@ func (p PeopleService) List(email string, displayName string) (*[]People, error)
 	if displayName != "" {
 		v.Add("displayName", displayName)
 	}
-	req, err := p.Client.NewGetRequest("/people?" + v.Encode())
+	if len(ids) > 0 {
+		v.Add("id", strings.Join(ids, ","))
+	}
+	if orgId != "" {
+		v.Add("orgId", orgId)
+	}
+	req, err := p.Client.NewGetRequest(ctx, "/people?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return p.Client.NewPager(req, paging), nil
+}
+
+func (p PeopleService) Get(ctx context.Context, id string) (*People, error) {
+	req, err := p.Client.NewGetRequest(ctx, "/people/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result People
+	_, err = p.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (p PeopleService) GetMe(ctx context.Context) (*People, error) {
+	return p.Get(ctx, "me")
+}
+
+// Create a person in your organization.  This requires an admin token.  At
+// least one email is required.
+func (p PeopleService) Create(ctx context.Context, person People) (*People, error) {
+	if len(person.Emails) == 0 {
+		return nil, errors.New("At least one email should be specified when creating a person")
+	}
+	req, err := p.Client.NewPostRequest(ctx, "/people", person)
 	if err != nil {
 		return nil, err
 	}
-	var result PeopleItems
+	var result People
 	_, err = p.Client.Do(req, &result)
 	if err != nil {
 		return nil, err
 	}
-	return &result.Items, nil
+	return &result, nil
 }
 
-func (p PeopleService) Get(id string) (*People, error) {
-	req, err := p.Client.NewGetRequest("/people/" + id)
+// Update the details of a person.  This requires an admin token.
+func (p PeopleService) Update(ctx context.Context, id string, person People) (*People, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a person")
+	}
+	update := personUpdate{
+		Emails:      person.Emails,
+		DisplayName: person.DisplayName,
+		NickName:    person.NickName,
+		FirstName:   person.FirstName,
+		LastName:    person.LastName,
+		Avatar:      person.Avatar,
+		OrgId:       person.OrgId,
+		Roles:       person.Roles,
+		Licenses:    person.Licenses,
+	}
+	// An empty list, not null, removes all roles or licenses.
+	if update.Roles == nil {
+		update.Roles = []string{}
+	}
+	if update.Licenses == nil {
+		update.Licenses = []string{}
+	}
+	req, err := p.Client.NewPutRequest(ctx, "/people/"+id, update)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +150,47 @@ func (p PeopleService) Get(id string) (*People, error) {
 	return &result, nil
 }
 
-func (p PeopleService) GetMe() (*People, error) {
-	return p.Get("me")
+// Delete a person.  This requires an admin token.
+func (p PeopleService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a person")
+	}
+	req, err := p.Client.NewDeleteRequest(ctx, "/people/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = p.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Mention returns the markdown markup that mentions person in a message.
+// person is an email address, a person id, or "all" to mention everyone in
+// the room.  The person is looked up to include their display name.
+func (p PeopleService) Mention(ctx context.Context, person string) (string, error) {
+	if person == "all" {
+		return "<@all>", nil
+	}
+	if strings.Contains(person, "@") {
+		pager, err := p.List(ctx, person, "", nil, "", util.Paging{})
+		if err != nil {
+			return "", err
+		}
+		var people []People
+		err = pager.Next(&people)
+		if err != nil {
+			return "", err
+		}
+		if len(people) == 0 {
+			return "", errors.New("No person found with email " + person)
+		}
+		return "<@personEmail:" + person + "|" + people[0].DisplayName + ">", nil
+	}
+	found, err := p.Get(ctx, person)
+	if err != nil {
+		return "", err
+	}
+	return "<@personId:" + found.Id + "|" + found.DisplayName + ">", nil
 }
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+)
+
+type RoleService struct {
+	Client *util.Client
+}
+
+type Role struct {
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+func (r RoleService) List(ctx context.Context, paging util.Paging) (*util.Pager, error) {
+	req, err := r.Client.NewGetRequest(ctx, "/roles")
+	if err != nil {
+		return nil, err
+	}
+	return r.Client.NewPager(req, paging), nil
+}
+
+func (r RoleService) Get(ctx context.Context, id string) (*Role, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a role")
+	}
+	req, err := r.Client.NewGetRequest(ctx, "/roles/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Role
+	_, err = r.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
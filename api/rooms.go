@@ -1,7 +1,11 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+	"strconv"
 )
 
 type RoomService struct {
@@ -9,34 +13,92 @@ type RoomService struct {
 }
 
 type Room struct {
-	Id           string `json:"id,omitempty"`
-	Title        string `json:"title,omitempty"`
-	SipAddress   string `json:"sipAddress,omitempty"`
-	Created      string `json:"created,omitempty"`
-	LastActivity string `json:"lastActivity,omitempty"`
-	IsLocked     bool   `json:"isLocked,omitempty"`
+	Id                 string `json:"id,omitempty"`
+	Title              string `json:"title,omitempty"`
+	SipAddress         string `json:"sipAddress,omitempty"`
+	Created            string `json:"created,omitempty"`
+	LastActivity       string `json:"lastActivity,omitempty"`
+	IsLocked           bool   `json:"isLocked,omitempty"`
+	IsAnnouncementOnly bool   `json:"isAnnouncementOnly,omitempty"`
+	TeamId             string `json:"teamId,omitempty"`
+	Type               string `json:"type,omitempty"`
+	CreatorId          string `json:"creatorId,omitempty"`
 }
 
-type RoomItems struct {
-	Items []Room `json:"items"`
+// roomUpdate is the body for a room update.
+type roomUpdate struct {
+	Title              string `json:"title"`
+	IsLocked           bool   `json:"isLocked"`
+	IsAnnouncementOnly bool   `json:"isAnnouncementOnly"`
+	TeamId             string `json:"teamId,omitempty"`
 }
 
-func (r RoomService) List() (*[]Room, error) {
-	req, err := r.Client.NewGetRequest("/rooms")
+// MeetingInfo holds the details needed to join the meeting of a room.
+type MeetingInfo struct {
+	RoomId               string `json:"roomId,omitempty"`
+	MeetingLink          string `json:"meetingLink,omitempty"`
+	SipAddress           string `json:"sipAddress,omitempty"`
+	MeetingNumber        string `json:"meetingNumber,omitempty"`
+	CallInTollFreeNumber string `json:"callInTollFreeNumber,omitempty"`
+	CallInTollNumber     string `json:"callInTollNumber,omitempty"`
+}
+
+// List the rooms you're in.  All filters are optional: roomType is "direct" or
+// "group", teamId limits the rooms to a team, sortBy is "id", "lastactivity"
+// or "created" and max limits the number of rooms per page (0 means the
+// service default).
+func (r RoomService) List(ctx context.Context, roomType string, teamId string, sortBy string, max int, paging util.Paging) (*util.Pager, error) {
+	v := url.Values{}
+	switch roomType {
+	case "":
+	case "direct", "group":
+		v.Add("type", roomType)
+	default:
+		return nil, errors.New("type should be direct or group")
+	}
+	if teamId != "" {
+		v.Add("teamId", teamId)
+	}
+	switch sortBy {
+	case "":
+	case "id", "lastactivity", "created":
+		v.Add("sortBy", sortBy)
+	default:
+		return nil, errors.New("sortBy should be id, lastactivity or created")
+	}
+	if max > 0 {
+		v.Add("max", strconv.Itoa(max))
+	}
+	path := "/rooms"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	req, err := r.Client.NewGetRequest(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var result RoomItems
+	return r.Client.NewPager(req, paging), nil
+}
+
+// Create a room with the given name.  If teamId is not empty, the room is
+// created inside that team.
+func (r RoomService) Create(ctx context.Context, name string, teamId string) (*Room, error) {
+	room := Room{Title: name, TeamId: teamId}
+	req, err := r.Client.NewPostRequest(ctx, "/rooms", room)
+	if err != nil {
+		return nil, err
+	}
+	var result Room
 	_, err = r.Client.Do(req, &result)
 	if err != nil {
 		return nil, err
 	}
-	return &result.Items, nil
+	return &result, nil
 }
 
-func (r RoomService) Create(name string) (*Room, error) {
-	room := Room{Title: name}
-	req, err := r.Client.NewPostRequest("/rooms", room)
+func (r RoomService) Get(ctx context.Context, id string) (*Room, error) {
+	// for now, we're always returning the SIP address.
+	req, err := r.Client.NewGetRequest(ctx, "/rooms/"+id+"?showSipAddress=true")
 	if err != nil {
 		return nil, err
 	}
@@ -48,13 +110,15 @@ func (r RoomService) Create(name string) (*Room, error) {
 	return &result, nil
 }
 
-func (r RoomService) Get(id string) (*Room, error) {
-	// for now, we're always returning the SIP address.
-	req, err := r.Client.NewGetRequest("/rooms/" + id + "?showSipAddress=true")
+func (r RoomService) GetMeetingInfo(ctx context.Context, id string) (*MeetingInfo, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting meeting info")
+	}
+	req, err := r.Client.NewGetRequest(ctx, "/rooms/"+id+"/meetingInfo")
 	if err != nil {
 		return nil, err
 	}
-	var result Room
+	var result MeetingInfo
 	_, err = r.Client.Do(req, &result)
 	if err != nil {
 		return nil, err
@@ -62,9 +126,21 @@ func (r RoomService) Get(id string) (*Room, error) {
 	return &result, nil
 }
 
-func (r RoomService) Update(id string, name string) (*Room, error) {
-	room := Room{Title: name}
-	req, err := r.Client.NewPutRequest("/rooms/"+id, room)
+// Update the title, lock state, announcement mode and team of a room.
+func (r RoomService) Update(ctx context.Context, id string, room Room) (*Room, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a room")
+	}
+	if room.Title == "" {
+		return nil, errors.New("title can't be empty when updating a room")
+	}
+	update := roomUpdate{
+		Title:              room.Title,
+		IsLocked:           room.IsLocked,
+		IsAnnouncementOnly: room.IsAnnouncementOnly,
+		TeamId:             room.TeamId,
+	}
+	req, err := r.Client.NewPutRequest(ctx, "/rooms/"+id, update)
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +152,8 @@ func (r RoomService) Update(id string, name string) (*Room, error) {
 	return &result, nil
 }
 
-func (r RoomService) Delete(id string) error {
-	req, err := r.Client.NewDeleteRequest("/rooms/" + id)
+func (r RoomService) Delete(ctx context.Context, id string) error {
+	req, err := r.Client.NewDeleteRequest(ctx, "/rooms/"+id)
 	if err != nil {
 		return err
 	}
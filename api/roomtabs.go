@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+)
+
+type RoomTabService struct {
+	Client *util.Client
+}
+
+// RoomTab is a content tab pinned in a room: a url with a display name.
+type RoomTab struct {
+	Id          string `json:"id,omitempty"`
+	RoomId      string `json:"roomId,omitempty"`
+	RoomType    string `json:"roomType,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	ContentUrl  string `json:"contentUrl,omitempty"`
+	CreatorId   string `json:"creatorId,omitempty"`
+	Created     string `json:"created,omitempty"`
+}
+
+func (r RoomTabService) List(ctx context.Context, roomId string, paging util.Paging) (*util.Pager, error) {
+	if roomId == "" {
+		return nil, errors.New("roomId can't be empty when listing room tabs")
+	}
+	v := url.Values{}
+	v.Add("roomId", roomId)
+	req, err := r.Client.NewGetRequest(ctx, "/room/tabs?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return r.Client.NewPager(req, paging), nil
+}
+
+func (r RoomTabService) Create(ctx context.Context, roomId, contentUrl, displayName string) (*RoomTab, error) {
+	if roomId == "" || contentUrl == "" || displayName == "" {
+		return nil, errors.New("roomId, contentUrl and displayName are required when creating a room tab")
+	}
+	tab := RoomTab{RoomId: roomId, ContentUrl: contentUrl, DisplayName: displayName}
+	req, err := r.Client.NewPostRequest(ctx, "/room/tabs", tab)
+	if err != nil {
+		return nil, err
+	}
+	var result RoomTab
+	_, err = r.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r RoomTabService) Get(ctx context.Context, id string) (*RoomTab, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a room tab")
+	}
+	req, err := r.Client.NewGetRequest(ctx, "/room/tabs/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result RoomTab
+	_, err = r.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Update changes the url and display name of a tab.  The API requires the
+// roomId of the tab as well as both values.
+func (r RoomTabService) Update(ctx context.Context, id, roomId, contentUrl, displayName string) (*RoomTab, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a room tab")
+	}
+	tab := RoomTab{RoomId: roomId, ContentUrl: contentUrl, DisplayName: displayName}
+	req, err := r.Client.NewPutRequest(ctx, "/room/tabs/"+id, tab)
+	if err != nil {
+		return nil, err
+	}
+	var result RoomTab
+	_, err = r.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r RoomTabService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a room tab")
+	}
+	req, err := r.Client.NewDeleteRequest(ctx, "/room/tabs/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = r.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
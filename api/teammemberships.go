@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+	"net/url"
+)
+
+type TeamMemberService struct {
+	Client *util.Client
+}
+
+type TeamMembership struct {
+	Id                string `json:"id,omitempty"`
+	TeamId            string `json:"teamId,omitempty"`
+	PersonId          string `json:"personId,omitempty"`
+	PersonEmail       string `json:"personEmail,omitempty"`
+	PersonDisplayName string `json:"personDisplayName,omitempty"`
+	IsModerator       bool   `json:"isModerator,omitempty"`
+	Created           string `json:"created,omitempty"`
+}
+
+// teamMembershipUpdate is the body for a team membership update.
+type teamMembershipUpdate struct {
+	IsModerator bool `json:"isModerator"`
+}
+
+func (t TeamMemberService) List(ctx context.Context, teamId string, paging util.Paging) (*util.Pager, error) {
+	if teamId == "" {
+		return nil, errors.New("teamId can't be empty when listing team memberships")
+	}
+	v := url.Values{}
+	v.Add("teamId", teamId)
+	req, err := t.Client.NewGetRequest(ctx, "/team/memberships?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return t.Client.NewPager(req, paging), nil
+}
+
+func (t TeamMemberService) Create(ctx context.Context, teamId, personId, personEmail string, isModerator bool) (*TeamMembership, error) {
+	if teamId == "" {
+		return nil, errors.New("teamId can't be empty when creating a team membership")
+	}
+	if personId == "" && personEmail == "" {
+		return nil, errors.New("personId or personEmail should be specified")
+	}
+	ms := TeamMembership{TeamId: teamId, PersonId: personId, PersonEmail: personEmail, IsModerator: isModerator}
+	req, err := t.Client.NewPostRequest(ctx, "/team/memberships", ms)
+	if err != nil {
+		return nil, err
+	}
+	var result TeamMembership
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamMemberService) Get(ctx context.Context, id string) (*TeamMembership, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a team membership")
+	}
+	req, err := t.Client.NewGetRequest(ctx, "/team/memberships/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result TeamMembership
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamMemberService) Update(ctx context.Context, id string, isModerator bool) (*TeamMembership, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a team membership")
+	}
+	ms := teamMembershipUpdate{IsModerator: isModerator}
+	req, err := t.Client.NewPutRequest(ctx, "/team/memberships/"+id, ms)
+	if err != nil {
+		return nil, err
+	}
+	var result TeamMembership
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamMemberService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a team membership")
+	}
+	req, err := t.Client.NewDeleteRequest(ctx, "/team/memberships/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = t.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
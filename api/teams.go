@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+)
+
+type TeamService struct {
+	Client *util.Client
+}
+
+type Team struct {
+	Id        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	CreatorId string `json:"creatorId,omitempty"`
+	Created   string `json:"created,omitempty"`
+}
+
+func (t TeamService) List(ctx context.Context, paging util.Paging) (*util.Pager, error) {
+	req, err := t.Client.NewGetRequest(ctx, "/teams")
+	if err != nil {
+		return nil, err
+	}
+	return t.Client.NewPager(req, paging), nil
+}
+
+func (t TeamService) Create(ctx context.Context, name string) (*Team, error) {
+	if name == "" {
+		return nil, errors.New("name can't be empty when creating a team")
+	}
+	team := Team{Name: name}
+	req, err := t.Client.NewPostRequest(ctx, "/teams", team)
+	if err != nil {
+		return nil, err
+	}
+	var result Team
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamService) Get(ctx context.Context, id string) (*Team, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a team")
+	}
+	req, err := t.Client.NewGetRequest(ctx, "/teams/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Team
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamService) Update(ctx context.Context, id string, name string) (*Team, error) {
+	team := Team{Name: name}
+	req, err := t.Client.NewPutRequest(ctx, "/teams/"+id, team)
+	if err != nil {
+		return nil, err
+	}
+	var result Team
+	_, err = t.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t TeamService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a team")
+	}
+	req, err := t.Client.NewDeleteRequest(ctx, "/teams/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = t.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil //success
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/tdeckers/sparkcli/util"
+)
+
+type WebhookService struct {
+	Client *util.Client
+}
+
+type Webhook struct {
+	Id        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	TargetUrl string `json:"targetUrl,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Event     string `json:"event,omitempty"`
+	Filter    string `json:"filter,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Created   string `json:"created,omitempty"`
+}
+
+// webhookUpdate is the body for a webhook update.
+type webhookUpdate struct {
+	Name      string `json:"name"`
+	TargetUrl string `json:"targetUrl"`
+	Secret    string `json:"secret"`
+}
+
+func (w WebhookService) List(ctx context.Context, paging util.Paging) (*util.Pager, error) {
+	req, err := w.Client.NewGetRequest(ctx, "/webhooks")
+	if err != nil {
+		return nil, err
+	}
+	return w.Client.NewPager(req, paging), nil
+}
+
+// Create registers a new webhook.  name, targetUrl, resource and event are
+// required, filter and secret are optional.
+func (w WebhookService) Create(ctx context.Context, name, targetUrl, resource, event, filter, secret string) (*Webhook, error) {
+	if name == "" || targetUrl == "" || resource == "" || event == "" {
+		return nil, errors.New("name, targetUrl, resource and event are required when creating a webhook")
+	}
+	hook := Webhook{
+		Name:      name,
+		TargetUrl: targetUrl,
+		Resource:  resource,
+		Event:     event,
+		Filter:    filter,
+		Secret:    secret,
+	}
+	req, err := w.Client.NewPostRequest(ctx, "/webhooks", hook)
+	if err != nil {
+		return nil, err
+	}
+	var result Webhook
+	_, err = w.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (w WebhookService) Get(ctx context.Context, id string) (*Webhook, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when getting a webhook")
+	}
+	req, err := w.Client.NewGetRequest(ctx, "/webhooks/"+id)
+	if err != nil {
+		return nil, err
+	}
+	var result Webhook
+	_, err = w.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Update changes the name, targetUrl and secret of a webhook.  The API
+// requires both name and targetUrl; resource, event and filter can't be
+// changed after creation.
+func (w WebhookService) Update(ctx context.Context, id, name, targetUrl, secret string) (*Webhook, error) {
+	if id == "" {
+		return nil, errors.New("id can't be empty when updating a webhook")
+	}
+	if name == "" || targetUrl == "" {
+		return nil, errors.New("name and targetUrl are required when updating a webhook")
+	}
+	hook := webhookUpdate{Name: name, TargetUrl: targetUrl, Secret: secret}
+	req, err := w.Client.NewPutRequest(ctx, "/webhooks/"+id, hook)
+	if err != nil {
+		return nil, err
+	}
+	var result Webhook
+	_, err = w.Client.Do(req, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (w WebhookService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id can't be empty when deleting a webhook")
+	}
+	req, err := w.Client.NewDeleteRequest(ctx, "/webhooks/"+id)
+	if err != nil {
+		return err
+	}
+	_, err = w.Client.Do(req, nil)
+	if err != nil {
+		return err
+	}
+	return nil //success
+}
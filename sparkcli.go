@@ -1,21 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/codegangsta/cli"
 	"github.com/tdeckers/sparkcli/api"
 	"github.com/tdeckers/sparkcli/util"
+	"io/ioutil"
 	"log" // TODO: change to https://github.com/Sirupsen/logrus
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func main() {
 	var jsonFlag bool
+	var timeout time.Duration
+	// ctx is cancelled on Ctrl-C or when the timeout expires, which stops
+	// the request in progress.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	config := util.GetConfiguration()
 	config.Load()
-	client := util.NewClient(config)
+	// client is created once the flags are parsed, see app.Before.
+	var client *util.Client
 	app := cli.NewApp()
 	app.Name = "sparkcli"
 	app.Usage = "Command Line Interface for Cisco Spark"
@@ -26,6 +39,41 @@ func main() {
 			Usage:       "return results as json",
 			Destination: &jsonFlag,
 		},
+		cli.BoolFlag{
+			Name:        "debug",
+			Usage:       "log debug details, like retried requests",
+			Destination: &util.Debug,
+		},
+		cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "give up when the command takes longer than this, e.g. 30s or 5m",
+			Destination: &timeout,
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		var err error
+		client, err = util.NewClient(config)
+		if err != nil {
+			fatal(err)
+		}
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			// A second Ctrl-C kills sparkcli right away.
+			signal.Stop(interrupt)
+			cancel()
+			// A request in progress fails with the cancelled context and
+			// exits through fatal.  Anything else, like reading stdin,
+			// isn't stopped by ctx, so exit here if it's still running.
+			time.Sleep(interruptGrace)
+			log.Println("Interrupted")
+			os.Exit(exitInterrupted)
+		}()
+		return nil
 	}
 	app.Commands = []cli.Command{
 		{
@@ -35,7 +83,7 @@ func main() {
 			Action: func(c *cli.Context) {
 				log.Println("Logging in")
 				login := util.NewLogin(config, client)
-				login.Authorize()
+				login.Authorize(ctx)
 			},
 		},
 		{
@@ -47,21 +95,42 @@ func main() {
 					Name:    "list",
 					Aliases: []string{"l"},
 					Usage:   "list all rooms",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "type",
+							Usage: "only list direct or group rooms",
+						},
+						cli.StringFlag{
+							Name:  "team, t",
+							Usage: "only list rooms of this team",
+						},
+						cli.StringFlag{
+							Name:  "sort, s",
+							Usage: "sort by id, lastactivity or created",
+						},
+						cli.IntFlag{
+							Name:  "max, m",
+							Usage: "maximum number of rooms per page",
+						},
+					}, pagingFlags()...),
 					Action: func(c *cli.Context) {
 						roomService := api.RoomService{Client: client}
-						rooms, err := roomService.List()
+						pager, err := roomService.List(ctx, c.String("type"), c.String("team"), c.String("sort"), c.Int("max"), paging(c))
 						if err != nil {
-							log.Fatalln(err)
-						} else {
-							if jsonFlag {
-								util.PrintJson(rooms)
-							} else {
-								// TODO: should I calculate room id length somehow?
-								fmt.Print("Id" + strings.Repeat(" ", 76) + "Title\n")
-								for _, room := range *rooms {
-									fmt.Printf("%s: %s\n", room.Id, room.Title)
-								}
+							fatal(err)
+						}
+						if !jsonFlag {
+							// TODO: should I calculate room id length somehow?
+							fmt.Print("Id" + strings.Repeat(" ", 76) + "Title\n")
+						}
+						var rooms []api.Room
+						err = printPages(pager, jsonFlag, &rooms, func() {
+							for _, room := range rooms {
+								fmt.Printf("%s: %s\n", room.Id, room.Title)
 							}
+						})
+						if err != nil {
+							fatal(err)
 						}
 					},
 				},
@@ -69,15 +138,22 @@ func main() {
 					Name:    "create",
 					Aliases: []string{"c"},
 					Usage:   "create a new room",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "team, t",
+							Usage: "id of the team to create the room in",
+						},
+					},
 					Action: func(c *cli.Context) {
 						if c.NArg() != 1 {
-							log.Fatal("Usage: sparkcli rooms create <name>")
+							log.Fatal("Usage: sparkcli rooms create [-team <teamId>] <name>")
 						}
 						name := c.Args().Get(0)
+						teamId := c.String("team")
 						roomService := api.RoomService{Client: client}
-						room, err := roomService.Create(name)
+						room, err := roomService.Create(ctx, name, teamId)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(room)
@@ -104,9 +180,9 @@ func main() {
 							}
 						}
 						roomService := api.RoomService{Client: client}
-						room, err := roomService.Get(id)
+						room, err := roomService.Get(ctx, id)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(room)
@@ -114,11 +190,153 @@ func main() {
 								fmt.Printf("Id:          %s\n", room.Id)
 								fmt.Printf("Title:       %s\n", room.Title)
 								fmt.Printf("Sip Address: %s\n", room.SipAddress)
+								fmt.Printf("Type:        %s\n", room.Type)
+								fmt.Printf("Creator:     %s\n", room.CreatorId)
+								fmt.Printf("Locked:      %t\n", room.IsLocked)
+								fmt.Printf("Announce:    %t\n", room.IsAnnouncementOnly)
+								if room.TeamId != "" {
+									fmt.Printf("Team:        %s\n", room.TeamId)
+								}
 								fmt.Printf("Created:     %s\n", room.Created)
 							}
 						}
 					},
 				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "change the title or team of a room",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "title",
+							Usage: "new title of the room",
+						},
+						cli.StringFlag{
+							Name:  "team, t",
+							Usage: "id of the team to move the room to",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 || (!c.IsSet("title") && !c.IsSet("team")) {
+							log.Fatal("Usage: sparkcli rooms update [-title <title>] [-team <teamId>] <id>")
+						}
+						id := c.Args().Get(0)
+						if id == "-" {
+							id = config.DefaultRoomId
+							if id == "" {
+								log.Fatal("Usage: sparkcli rooms update [-title <title>] [-team <teamId>] <id> (no default room configured)")
+							}
+						}
+						roomService := api.RoomService{Client: client}
+						room, err := roomService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						if c.IsSet("title") {
+							room.Title = c.String("title")
+						}
+						if c.IsSet("team") {
+							room.TeamId = c.String("team")
+						}
+						room, err = roomService.Update(ctx, id, *room)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(room)
+							} else {
+								fmt.Printf("Id:          %s\n", room.Id)
+								fmt.Printf("Title:       %s\n", room.Title)
+								if room.TeamId != "" {
+									fmt.Printf("Team:        %s\n", room.TeamId)
+								}
+							}
+						}
+					},
+				},
+				{
+					Name:  "lock",
+					Usage: "lock a room, so only moderators can manage it (any member can lock an unlocked room)",
+					Action: func(c *cli.Context) {
+						room := moderateRoom(ctx, c, client, "lock", func(room *api.Room) {
+							room.IsLocked = true
+						})
+						if jsonFlag {
+							util.PrintJson(room)
+						} else {
+							fmt.Println("Room locked.")
+						}
+					},
+				},
+				{
+					Name:  "unlock",
+					Usage: "unlock a room",
+					Action: func(c *cli.Context) {
+						room := moderateRoom(ctx, c, client, "unlock", func(room *api.Room) {
+							room.IsLocked = false
+							room.IsAnnouncementOnly = false
+						})
+						if jsonFlag {
+							util.PrintJson(room)
+						} else {
+							fmt.Println("Room unlocked.")
+						}
+					},
+				},
+				{
+					Name:  "announce",
+					Usage: "turn announcement mode on (or off), so only moderators can post",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "off",
+							Usage: "turn announcement mode off",
+						},
+					},
+					Action: func(c *cli.Context) {
+						on := !c.Bool("off")
+						room := moderateRoom(ctx, c, client, "announce", func(room *api.Room) {
+							room.IsAnnouncementOnly = on
+						})
+						if jsonFlag {
+							util.PrintJson(room)
+						} else if on {
+							fmt.Println("Announcement mode on.")
+						} else {
+							fmt.Println("Announcement mode off.")
+						}
+					},
+				},
+				{
+					Name:  "meeting-info",
+					Usage: "get the meeting details of a room",
+					Action: func(c *cli.Context) {
+						if c.NArg() > 1 {
+							log.Fatal("Usage: sparkcli rooms meeting-info <id>")
+						}
+						id := c.Args().Get(0)
+						if id == "" || id == "-" { // try default room
+							id = config.DefaultRoomId
+							if id == "" {
+								log.Fatal("Usage: sparkcli rooms meeting-info <id> (no default room configured)")
+							}
+						}
+						roomService := api.RoomService{Client: client}
+						info, err := roomService.GetMeetingInfo(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(info)
+							} else {
+								fmt.Printf("Meeting Link:    %s\n", info.MeetingLink)
+								fmt.Printf("Sip Address:     %s\n", info.SipAddress)
+								fmt.Printf("Meeting Number:  %s\n", info.MeetingNumber)
+								fmt.Printf("Toll-free Phone: %s\n", info.CallInTollFreeNumber)
+								fmt.Printf("Toll Phone:      %s\n", info.CallInTollNumber)
+							}
+						}
+					},
+				},
 				{
 					Name:    "delete",
 					Aliases: []string{"d"},
@@ -129,10 +347,10 @@ func main() {
 						}
 						id := c.Args().Get(0)
 						roomService := api.RoomService{Client: client}
-						err := roomService.Delete(id)
+						err := roomService.Delete(ctx, id)
 						//TODO: if error is '400 Bad Request', try deleting by name?
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if !jsonFlag {
 								fmt.Println("Room deleted.")
@@ -141,6 +359,141 @@ func main() {
 						}
 					},
 				},
+				{
+					Name:  "tabs",
+					Usage: "operations on the content tabs of a room",
+					Subcommands: []cli.Command{
+						{
+							Name:    "list",
+							Aliases: []string{"l"},
+							Usage:   "list the tabs of a room",
+							Flags:   pagingFlags(),
+							Action: func(c *cli.Context) {
+								if c.NArg() > 1 {
+									log.Fatal("Usage: sparkcli rooms tabs list <room>")
+								}
+								id := c.Args().Get(0)
+								if id == "" || id == "-" { // try default room
+									id = config.DefaultRoomId
+									if id == "" {
+										log.Fatal("Usage: sparkcli rooms tabs list <room> (no default room configured)")
+									}
+								}
+								tabService := api.RoomTabService{Client: client}
+								pager, err := tabService.List(ctx, id, paging(c))
+								if err != nil {
+									fatal(err)
+								}
+								var tabs []api.RoomTab
+								err = printPages(pager, jsonFlag, &tabs, func() {
+									for _, tab := range tabs {
+										fmt.Printf("%s:\n", tab.Id)
+										fmt.Printf("   Name: %s\n", tab.DisplayName)
+										fmt.Printf("   Url:  %s\n", tab.ContentUrl)
+									}
+								})
+								if err != nil {
+									fatal(err)
+								}
+							},
+						},
+						{
+							Name:    "add",
+							Aliases: []string{"a"},
+							Usage:   "pin a url as a tab in a room",
+							Action: func(c *cli.Context) {
+								if c.NArg() < 3 {
+									log.Fatal("Usage: sparkcli rooms tabs add <room> <url> <name>")
+								}
+								id := c.Args().Get(0)
+								if id == "-" {
+									id = config.DefaultRoomId
+									if id == "" {
+										log.Fatal("Usage: sparkcli rooms tabs add <room> <url> <name> (no default room configured)")
+									}
+								}
+								contentUrl := c.Args().Get(1)
+								name := strings.Join(c.Args()[2:], " ")
+								tabService := api.RoomTabService{Client: client}
+								tab, err := tabService.Create(ctx, id, contentUrl, name)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(tab)
+									} else {
+										fmt.Print(tab.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:    "update",
+							Aliases: []string{"u"},
+							Usage:   "change the url or name of a tab",
+							Flags: []cli.Flag{
+								cli.StringFlag{
+									Name:  "url, u",
+									Usage: "new url of the tab",
+								},
+								cli.StringFlag{
+									Name:  "name, n",
+									Usage: "new name of the tab",
+								},
+							},
+							Action: func(c *cli.Context) {
+								if c.NArg() != 1 {
+									log.Fatal("Usage: sparkcli rooms tabs update [-u <url>] [-n <name>] <tabId>")
+								}
+								id := c.Args().Get(0)
+								tabService := api.RoomTabService{Client: client}
+								tab, err := tabService.Get(ctx, id)
+								if err != nil {
+									fatal(err)
+								}
+								if c.IsSet("url") {
+									tab.ContentUrl = c.String("url")
+								}
+								if c.IsSet("name") {
+									tab.DisplayName = c.String("name")
+								}
+								tab, err = tabService.Update(ctx, id, tab.RoomId, tab.ContentUrl, tab.DisplayName)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(tab)
+									} else {
+										fmt.Printf("Id:   %s\n", tab.Id)
+										fmt.Printf("Name: %s\n", tab.DisplayName)
+										fmt.Printf("Url:  %s\n", tab.ContentUrl)
+										fmt.Printf("Room: %s\n", tab.RoomId)
+									}
+								}
+							},
+						},
+						{
+							Name:    "remove",
+							Aliases: []string{"r"},
+							Usage:   "remove a tab from its room",
+							Action: func(c *cli.Context) {
+								if c.NArg() != 1 {
+									log.Fatal("Usage: sparkcli rooms tabs remove <tabId>")
+								}
+								id := c.Args().Get(0)
+								tabService := api.RoomTabService{Client: client}
+								err := tabService.Delete(ctx, id)
+								if err != nil {
+									fatal(err)
+								} else {
+									if !jsonFlag {
+										fmt.Println("Tab removed.")
+									}
+								}
+							},
+						},
+					},
+				},
 				// Convenience actions (not available in Cisco Spark API)
 				{
 					Name:  "default",
@@ -170,11 +523,36 @@ func main() {
 					Name:    "list",
 					Aliases: []string{"l"},
 					Usage:   "list all messages",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "parent, p",
+							Usage: "only list the replies to this message",
+						},
+						cli.BoolFlag{
+							Name:  "threaded, t",
+							Usage: "show replies indented under their parent",
+						},
+						cli.BoolFlag{
+							Name:  "mentioned, me",
+							Usage: "only list messages that mention you",
+						},
+						cli.StringFlag{
+							Name:  "before, b",
+							Usage: "only list messages sent before this time (ISO 8601, or a duration ago like 7d or 12h)",
+						},
+						cli.StringFlag{
+							Name:  "before-message, bm",
+							Usage: "only list messages sent before this message id",
+						},
+						cli.IntFlag{
+							Name:  "max, m",
+							Usage: "maximum number of messages per page",
+						},
+					}, pagingFlags()...),
 					Action: func(c *cli.Context) {
-						// TODO: add limiters (num, before, beforeMessage)
 						// If no arg provided, also use default room.
 						if c.NArg() > 1 {
-							log.Fatal("Usage: sparkcli messages list <roomid>")
+							log.Fatal("Usage: sparkcli messages list [-p <parentId>] [-t] [-me] [-b <time>] [-bm <messageId>] [-m <max>] <roomid>")
 						}
 						id := c.Args().Get(0)
 						if id == "" {
@@ -185,17 +563,39 @@ func main() {
 							}
 						}
 						msgService := api.MessageService{Client: client}
-						msgs, err := msgService.List(id)
+						before, err := parseTime(c.String("before"))
+						if err != nil {
+							fatal(err)
+						}
+						filter := api.MessageFilter{
+							ParentId:      c.String("parent"),
+							Before:        before,
+							BeforeMessage: c.String("before-message"),
+							Max:           c.Int("max"),
+						}
+						if c.Bool("mentioned") {
+							filter.MentionedPeople = "me"
+						}
+						pager, err := msgService.List(ctx, id, filter, paging(c))
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
+						}
+						var msgs []api.Message
+						if c.Bool("threaded") && !jsonFlag {
+							// Replies can only be placed once all pages are in.
+							err = pager.Collect(&msgs)
+							if err == nil {
+								printThreaded(msgs)
+							}
 						} else {
-							if jsonFlag {
-								util.PrintJson(msgs)
-							} else {
-								for _, msg := range *msgs {
-									fmt.Printf("[%v] %v: %v\n", msg.Created, msg.PersonEmail, msg.Text)
+							err = printPages(pager, jsonFlag, &msgs, func() {
+								for _, msg := range msgs {
+									fmt.Printf("[%v] %v: %v\n", msg.Created, msg.PersonEmail, msgBody(msg))
 								}
-							}
+							})
+						}
+						if err != nil {
+							fatal(err)
 						}
 					},
 				},
@@ -207,6 +607,12 @@ func main() {
 						{
 							Name:  "text",
 							Usage: "create a new text message",
+							Flags: []cli.Flag{
+								cli.StringSliceFlag{
+									Name:  "mention",
+									Usage: "mention a person (email, person id or all); can be repeated",
+								},
+							},
 							Action: func(c *cli.Context) {
 								// TODO: change this to take all args after the second as additional text.
 								if c.NArg() < 1 {
@@ -222,9 +628,109 @@ func main() {
 								}
 								msgTxt := strings.Join(c.Args().Tail(), " ")
 								msgService := api.MessageService{Client: client}
-								msg, err := msgService.Create(id, msgTxt)
+								var msg *api.Message
+								var err error
+								if mentions := c.StringSlice("mention"); len(mentions) > 0 {
+									// Mentions only work in markdown.
+									msgTxt, err = withMentions(ctx, client, mentions, msgTxt)
+									if err != nil {
+										fatal(err)
+									}
+									msg, err = msgService.CreateMarkdown(ctx, id, "", msgTxt)
+								} else {
+									msg, err = msgService.Create(ctx, id, "", msgTxt)
+								}
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(msg)
+									} else {
+										fmt.Print(msg.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:  "markdown",
+							Usage: "create a new markdown message",
+							Flags: []cli.Flag{
+								cli.StringFlag{
+									Name:  "file, f",
+									Usage: "read the markdown from a file",
+								},
+								cli.StringSliceFlag{
+									Name:  "mention",
+									Usage: "mention a person (email, person id or all); can be repeated",
+								},
+							},
+							Action: func(c *cli.Context) {
+								if c.NArg() < 1 {
+									log.Fatal("Usage: sparkcli messages create markdown [-f <file>] <room> [<msg>]")
+								}
+								id := c.Args().Get(0)
+								if id == "-" {
+									id = config.DefaultRoomId
+									if id == "" {
+										log.Println("No default room configured.")
+										log.Fatal("Usage: sparkcli messages create markdown <room> <msg>")
+									}
+								}
+								body, err := readBody(c.Args().Tail(), c.String("file"))
+								if err != nil {
+									fatal(err)
+								}
+								body, err = withMentions(ctx, client, c.StringSlice("mention"), body)
+								if err != nil {
+									fatal(err)
+								}
+								msgService := api.MessageService{Client: client}
+								msg, err := msgService.CreateMarkdown(ctx, id, "", body)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(msg)
+									} else {
+										fmt.Print(msg.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:  "card",
+							Usage: "send an Adaptive Card",
+							Flags: []cli.Flag{
+								cli.StringFlag{
+									Name:  "file, f",
+									Usage: "file with the card JSON (- for stdin)",
+								},
+								cli.StringFlag{
+									Name:  "fallback-text, t",
+									Value: "Adaptive Card",
+									Usage: "text shown by clients that can't render cards",
+								},
+							},
+							Action: func(c *cli.Context) {
+								if c.NArg() != 1 || c.String("file") == "" {
+									log.Fatal("Usage: sparkcli messages create card -f <card.json> [-t <fallback text>] <room>")
+								}
+								id := c.Args().Get(0)
+								if id == "-" {
+									id = config.DefaultRoomId
+									if id == "" {
+										log.Println("No default room configured.")
+										log.Fatal("Usage: sparkcli messages create card -f <card.json> <room>")
+									}
+								}
+								card, err := readBody(nil, c.String("file"))
+								if err != nil {
+									fatal(err)
+								}
+								msgService := api.MessageService{Client: client}
+								msg, err := msgService.CreateCard(ctx, id, []byte(card), c.String("fallback-text"))
 								if err != nil {
-									log.Fatalln(err)
+									fatal(err)
 								} else {
 									if jsonFlag {
 										util.PrintJson(msg)
@@ -251,9 +757,9 @@ func main() {
 								}
 								filePath := strings.Join(c.Args().Tail(), " ")
 								msgService := api.MessageService{Client: client}
-								msg, err := msgService.CreateFile(id, filePath)
+								msg, err := msgService.CreateFile(ctx, id, filePath)
 								if err != nil {
-									log.Fatalln(err)
+									fatal(err)
 								} else {
 									if jsonFlag {
 										util.PrintJson(msg)
@@ -266,34 +772,266 @@ func main() {
 					},
 				},
 				{
-					Name:    "get",
-					Aliases: []string{"g"},
-					Usage:   "get message details",
-					Action: func(c *cli.Context) {
-						if c.NArg() != 1 {
-							log.Fatal("Usage: sparkcli messages get <id>")
-						}
-						id := c.Args().Get(0)
-						msgService := api.MessageService{Client: client}
-						msg, err := msgService.Get(id)
-						if err != nil {
-							log.Fatalln(err)
-						} else {
-							if jsonFlag {
-								util.PrintJson(msg)
-							} else {
-								fmt.Printf("Id:            %s\n", msg.Id)
-								fmt.Printf("PersonId:      %s\n", msg.PersonId)
-								fmt.Printf("PersonEmail:   %s\n", msg.PersonEmail)
-								fmt.Printf("RoomId:        %s\n", msg.RoomId)
-								fmt.Printf("Text:          %s\n", msg.Text)
-								fmt.Printf("ToPersonId:    %s\n", msg.ToPersonId)
-								fmt.Printf("ToPersonEmail: %s\n", msg.ToPersonEmail)
+					Name:    "direct",
+					Aliases: []string{"dm"},
+					Usage:   "send and list 1:1 messages with a person",
+					Subcommands: []cli.Command{
+						{
+							Name:  "text",
+							Usage: "send a text message to a person",
+							Action: func(c *cli.Context) {
+								if c.NArg() < 2 {
+									log.Fatal("Usage: sparkcli messages direct text <email|personId> <msg>")
+								}
+								person := c.Args().Get(0)
+								msgTxt := strings.Join(c.Args().Tail(), " ")
+								msgService := api.MessageService{Client: client}
+								msg, err := msgService.CreateDirect(ctx, person, msgTxt)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(msg)
+									} else {
+										fmt.Print(msg.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:  "markdown",
+							Usage: "send a markdown message to a person",
+							Flags: []cli.Flag{
+								cli.StringFlag{
+									Name:  "file, f",
+									Usage: "read the markdown from a file",
+								},
+							},
+							Action: func(c *cli.Context) {
+								if c.NArg() < 1 {
+									log.Fatal("Usage: sparkcli messages direct markdown [-f <file>] <email|personId> [<msg>]")
+								}
+								person := c.Args().Get(0)
+								body, err := readBody(c.Args().Tail(), c.String("file"))
+								if err != nil {
+									fatal(err)
+								}
+								msgService := api.MessageService{Client: client}
+								msg, err := msgService.CreateDirectMarkdown(ctx, person, body)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(msg)
+									} else {
+										fmt.Print(msg.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:  "file",
+							Usage: "send an attachment to a person",
+							Action: func(c *cli.Context) {
+								if c.NArg() < 2 {
+									log.Fatal("Usage: sparkcli messages direct file <email|personId> <file>")
+								}
+								person := c.Args().Get(0)
+								filePath := strings.Join(c.Args().Tail(), " ")
+								msgService := api.MessageService{Client: client}
+								msg, err := msgService.CreateDirectFile(ctx, person, filePath)
+								if err != nil {
+									fatal(err)
+								} else {
+									if jsonFlag {
+										util.PrintJson(msg)
+									} else {
+										fmt.Print(msg.Id)
+									}
+								}
+							},
+						},
+						{
+							Name:    "list",
+							Aliases: []string{"l"},
+							Usage:   "list the 1:1 messages with a person",
+							Flags:   pagingFlags(),
+							Action: func(c *cli.Context) {
+								if c.NArg() != 1 {
+									log.Fatal("Usage: sparkcli messages direct list <email|personId>")
+								}
+								person := c.Args().Get(0)
+								msgService := api.MessageService{Client: client}
+								pager, err := msgService.ListDirect(ctx, person, paging(c))
+								if err != nil {
+									fatal(err)
+								}
+								var msgs []api.Message
+								err = printPages(pager, jsonFlag, &msgs, func() {
+									for _, msg := range msgs {
+										fmt.Printf("[%v] %v: %v\n", msg.Created, msg.PersonEmail, msgBody(msg))
+									}
+								})
+								if err != nil {
+									fatal(err)
+								}
+							},
+						},
+					},
+				},
+				{
+					Name:    "reply",
+					Aliases: []string{"r"},
+					Usage:   "reply to a message in its thread",
+					Action: func(c *cli.Context) {
+						if c.NArg() < 2 {
+							log.Fatal("Usage: sparkcli messages reply <messageId> <msg>")
+						}
+						parentId := c.Args().Get(0)
+						msgTxt := strings.Join(c.Args().Tail(), " ")
+						msgService := api.MessageService{Client: client}
+						parent, err := msgService.Get(ctx, parentId)
+						if err != nil {
+							fatal(err)
+						}
+						// Threads are only one level deep, so replying to a reply
+						// goes into the thread of its parent.
+						if parent.ParentId != "" {
+							parentId = parent.ParentId
+						}
+						msg, err := msgService.Create(ctx, parent.RoomId, parentId, msgTxt)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(msg)
+							} else {
+								fmt.Print(msg.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "edit",
+					Aliases: []string{"e"},
+					Usage:   "edit a message you sent before",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "markdown, m",
+							Usage: "the new body is markdown",
+						},
+						cli.StringFlag{
+							Name:  "file, f",
+							Usage: "read the new body from a file",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if c.NArg() < 1 {
+							log.Fatal("Usage: sparkcli messages edit [-m] [-f <file>] <id> [<msg>]")
+						}
+						id := c.Args().Get(0)
+						body, err := readBody(c.Args().Tail(), c.String("file"))
+						if err != nil {
+							fatal(err)
+						}
+						msgService := api.MessageService{Client: client}
+						// The update needs the room of the message.
+						current, err := msgService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						var msg *api.Message
+						if c.Bool("markdown") {
+							msg, err = msgService.UpdateMarkdown(ctx, id, current.RoomId, body)
+						} else {
+							msg, err = msgService.Update(ctx, id, current.RoomId, body)
+						}
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(msg)
+							} else {
+								fmt.Print(msg.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get message details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli messages get <id>")
+						}
+						id := c.Args().Get(0)
+						msgService := api.MessageService{Client: client}
+						msg, err := msgService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(msg)
+							} else {
+								fmt.Printf("Id:            %s\n", msg.Id)
+								fmt.Printf("PersonId:      %s\n", msg.PersonId)
+								fmt.Printf("PersonEmail:   %s\n", msg.PersonEmail)
+								fmt.Printf("RoomId:        %s\n", msg.RoomId)
+								if msg.ParentId != "" {
+									fmt.Printf("ParentId:      %s\n", msg.ParentId)
+								}
+								fmt.Printf("Text:          %s\n", msg.Text)
+								if msg.Markdown != "" {
+									fmt.Printf("Markdown:      %s\n", msg.Markdown)
+								}
+								for _, file := range msg.Files {
+									fmt.Printf("File:          %s\n", file)
+								}
+								fmt.Printf("ToPersonId:    %s\n", msg.ToPersonId)
+								fmt.Printf("ToPersonEmail: %s\n", msg.ToPersonEmail)
 								fmt.Printf("Created:       %s\n", msg.Created)
 							}
 						}
 					},
 				},
+				{
+					Name:  "download",
+					Usage: "download the attachments of a message",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "dir, d",
+							Value: ".",
+							Usage: "directory to save the files in",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli messages download [-d <dir>] <id>")
+						}
+						id := c.Args().Get(0)
+						msgService := api.MessageService{Client: client}
+						msg, err := msgService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						if len(msg.Files) == 0 {
+							log.Fatal("Message has no attachments.")
+						}
+						paths, err := msgService.DownloadFiles(ctx, msg, c.String("dir"))
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(paths)
+							} else {
+								for _, path := range paths {
+									fmt.Println(path)
+								}
+							}
+						}
+					},
+				},
 				{
 					Name:    "delete",
 					Aliases: []string{"d"},
@@ -304,9 +1042,9 @@ func main() {
 						}
 						id := c.Args().Get(0)
 						msgService := api.MessageService{Client: client}
-						err := msgService.Delete(id)
+						err := msgService.Delete(ctx, id)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if !jsonFlag {
 								fmt.Print("Message deleted.")
@@ -325,26 +1063,30 @@ func main() {
 					Name:    "get",
 					Aliases: []string{"g"},
 					Usage:   "get your details",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "expand, x",
+							Usage: "show role and license names instead of ids",
+						},
+					},
 					Action: func(c *cli.Context) {
 						id := "me"
 						if c.NArg() == 1 { // if argument, use that as id
 							id = c.Args().Get(0)
 						}
 						peopleService := api.PeopleService{Client: client}
-						person, err := peopleService.Get(id)
+						person, err := peopleService.Get(ctx, id)
+						if err == nil && c.Bool("expand") {
+							err = expandPerson(ctx, client, person)
+						}
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(person)
 							} else {
-								fmt.Printf("Id:      %s\n", person.Id)
-								fmt.Printf("Name:    %s\n", person.DisplayName)
-								for _, email := range person.Emails {
-									fmt.Printf("Email:   %s\n", email)
-								}
-								fmt.Printf("Avatar:  %s\n", person.Avatar)
-								fmt.Printf("Created: %s\n", person.Created)
+								fmt.Printf("Id:            %s\n", person.Id)
+								printPerson(person, "")
 							}
 						}
 
@@ -354,7 +1096,7 @@ func main() {
 					Name:    "list",
 					Aliases: []string{"l"},
 					Usage:   "list people",
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:  "email, e",
 							Usage: "email to search for",
@@ -363,35 +1105,110 @@ func main() {
 							Name:  "name, n",
 							Usage: "name to search for (startWith function)",
 						},
-					},
+						cli.StringFlag{
+							Name:  "id",
+							Usage: "comma separated list of person ids",
+						},
+						cli.StringFlag{
+							Name:  "org, o",
+							Usage: "organization to search in",
+						},
+					}, pagingFlags()...),
 					Action: func(c *cli.Context) {
 						email := c.String("email")
 						name := c.String("name")
+						var ids []string
+						if c.String("id") != "" {
+							ids = strings.Split(c.String("id"), ",")
+						}
+						peopleService := api.PeopleService{Client: client}
+						pager, err := peopleService.List(ctx, email, name, ids, c.String("org"), paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var people []api.People
+						err = printPages(pager, jsonFlag, &people, func() {
+							for _, person := range people {
+								fmt.Printf("%s:\n", person.Id)
+								printPerson(&person, "   ")
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "create a person (admin only)",
+					Flags:   personFlags(),
+					Action: func(c *cli.Context) {
+						if len(c.StringSlice("email")) == 0 {
+							log.Fatal("Usage: sparkcli people create -e <email> [-n <name>] [...]")
+						}
+						var person api.People
+						updatePerson(c, &person)
 						peopleService := api.PeopleService{Client: client}
-						people, err := peopleService.List(email, name)
+						created, err := peopleService.Create(ctx, person)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
-								util.PrintJson(people)
+								util.PrintJson(created)
 							} else {
-								for _, person := range *people {
-									fmt.Printf("%s:\n", person.Id)
-									fmt.Printf("   Name:    %s\n", person.DisplayName)
-									fmt.Printf("   Email:   ")
-									for i, email := range person.Emails {
-										if i == 0 {
-											fmt.Print(email)
-										} else {
-											fmt.Printf(", %s", email)
-										}
-									}
-									fmt.Println()
-									fmt.Printf("   Avatar:  %s\n", person.Avatar)
-									fmt.Printf("   Created: %s\n", person.Created)
-								}
+								// Print just the id, so can assign to env variable if desired.
+								fmt.Print(created.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "update a person (admin only)",
+					Flags:   personFlags(),
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli people update [-e <email>] [-n <name>] [...] <id>")
+						}
+						id := c.Args().Get(0)
+						peopleService := api.PeopleService{Client: client}
+						person, err := peopleService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						updatePerson(c, person)
+						updated, err := peopleService.Update(ctx, id, *person)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(updated)
+							} else {
+								fmt.Printf("Id:            %s\n", updated.Id)
+								printPerson(updated, "")
+							}
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "delete a person (admin only)",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli people delete <id>")
+						}
+						id := c.Args().Get(0)
+						peopleService := api.PeopleService{Client: client}
+						err := peopleService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Person deleted.")
 							}
-
 						}
 					},
 				},
@@ -406,7 +1223,7 @@ func main() {
 					Name:    "list",
 					Aliases: []string{"l"},
 					Usage:   "list memberships",
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:  "room, r",
 							Usage: "search by room id",
@@ -419,7 +1236,7 @@ func main() {
 							Name:  "email, e",
 							Usage: "filter by email",
 						},
-					},
+					}, pagingFlags()...),
 					Action: func(c *cli.Context) {
 						roomId := c.String("room")
 						if roomId == "-" {
@@ -432,21 +1249,22 @@ func main() {
 						personId := c.String("personid")
 						personEmail := c.String("email")
 						memberService := api.MemberService{Client: client}
-						mss, err := memberService.List(roomId, personId, personEmail)
+						pager, err := memberService.List(ctx, roomId, personId, personEmail, paging(c))
 						if err != nil {
-							log.Fatalln(err)
-						} else {
-							if jsonFlag {
-								util.PrintJson(mss)
-							} else {
-								for _, ms := range *mss {
-									fmt.Printf("%s:\n", ms.Id)
-									fmt.Printf("   Name: %s\n", ms.PersonDisplayName)
-									fmt.Printf("   Email: %s\n", ms.PersonEmail)
-									fmt.Printf("   Room: %s\n", ms.RoomId)
-									fmt.Printf("   Created: %s\n", ms.Created)
-								}
+							fatal(err)
+						}
+						var mss []api.Membership
+						err = printPages(pager, jsonFlag, &mss, func() {
+							for _, ms := range mss {
+								fmt.Printf("%s:\n", ms.Id)
+								fmt.Printf("   Name: %s\n", ms.PersonDisplayName)
+								fmt.Printf("   Email: %s\n", ms.PersonEmail)
+								fmt.Printf("   Room: %s\n", ms.RoomId)
+								fmt.Printf("   Created: %s\n", ms.Created)
 							}
+						})
+						if err != nil {
+							fatal(err)
 						}
 					},
 				},
@@ -481,9 +1299,9 @@ func main() {
 						personId := c.String("personid")
 						personEmail := c.String("email")
 						memberService := api.MemberService{Client: client}
-						ms, err := memberService.Create(roomId, personId, personEmail)
+						ms, err := memberService.Create(ctx, roomId, personId, personEmail)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(ms)
@@ -508,18 +1326,21 @@ func main() {
 						}
 						id := c.Args().Get(0)
 						msService := api.MemberService{Client: client}
-						ms, err := msService.Get(id)
+						ms, err := msService.Get(ctx, id)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(ms)
 							} else {
-								fmt.Printf("Id:      %s\n", ms.Id)
-								fmt.Printf("Name:    %s\n", ms.PersonDisplayName)
-								fmt.Printf("Email:   %s\n", ms.PersonEmail)
-								fmt.Printf("Room:    %s\n", ms.RoomId)
-								fmt.Printf("Created: %s\n", ms.Created)
+								fmt.Printf("Id:        %s\n", ms.Id)
+								fmt.Printf("Name:      %s\n", ms.PersonDisplayName)
+								fmt.Printf("Email:     %s\n", ms.PersonEmail)
+								fmt.Printf("Room:      %s\n", ms.RoomId)
+								fmt.Printf("Moderator: %t\n", ms.IsModerator)
+								fmt.Printf("Monitor:   %t\n", ms.IsMonitor)
+								fmt.Printf("Hidden:    %t\n", ms.IsRoomHidden)
+								fmt.Printf("Created:   %s\n", ms.Created)
 							}
 						}
 
@@ -534,54 +1355,1484 @@ func main() {
 							Name:  "moderator, m",
 							Usage: "set moderator role for the membership",
 						},
+						cli.BoolFlag{
+							Name:  "hidden",
+							Usage: "hide the room from the room list",
+						},
 					},
 					Action: func(c *cli.Context) {
-						if c.NArg() != 1 {
-							log.Fatal("Usage: sparkcli memberships update -moderator <id>")
+						if c.NArg() != 1 || (!c.IsSet("moderator") && !c.IsSet("hidden")) {
+							log.Fatal("Usage: sparkcli memberships update [-moderator=true|false] [-hidden=true|false] <id>")
 						}
 						id := c.Args().Get(0)
-						// TODO: avoid doing update if flag is not present.
-						moderator := c.Bool("moderator")
 						msService := api.MemberService{Client: client}
-						ms, err := msService.Update(id, moderator)
+						current, err := msService.Get(ctx, id)
 						if err != nil {
-							log.Fatalln(err)
+							fatal(err)
+						}
+						moderator := current.IsModerator
+						if c.IsSet("moderator") {
+							moderator = c.Bool("moderator")
+						}
+						hidden := current.IsRoomHidden
+						if c.IsSet("hidden") {
+							hidden = c.Bool("hidden")
+						}
+						ms, err := msService.Update(ctx, id, moderator, hidden)
+						if err != nil {
+							fatal(err)
 						} else {
 							if jsonFlag {
 								util.PrintJson(ms)
 							} else {
-								fmt.Printf("Id:      %s\n", ms.Id)
-								fmt.Printf("Name:    %s\n", ms.PersonDisplayName)
-								fmt.Printf("Email:   %s\n", ms.PersonEmail)
-								fmt.Printf("Room:    %s\n", ms.RoomId)
-								fmt.Printf("Created: %s\n", ms.Created)
+								fmt.Printf("Id:        %s\n", ms.Id)
+								fmt.Printf("Name:      %s\n", ms.PersonDisplayName)
+								fmt.Printf("Email:     %s\n", ms.PersonEmail)
+								fmt.Printf("Room:      %s\n", ms.RoomId)
+								fmt.Printf("Moderator: %t\n", ms.IsModerator)
+								fmt.Printf("Monitor:   %t\n", ms.IsMonitor)
+								fmt.Printf("Hidden:    %t\n", ms.IsRoomHidden)
+								fmt.Printf("Created:   %s\n", ms.Created)
 							}
 						}
 					},
 				},
 				{
-					Name:    "delete",
-					Aliases: []string{"d"},
-					Usage:   "delete membership",
+					Name:    "mine",
+					Aliases: []string{"m"},
+					Usage:   "list all your room memberships",
 					Action: func(c *cli.Context) {
-						if c.NArg() != 1 {
-							log.Fatal("Usage: sparkcli memberships delete <id>")
+						// Without filters, the service returns the memberships of
+						// the current user.
+						memberService := api.MemberService{Client: client}
+						pager, err := memberService.List(ctx, "", "", "", util.Paging{All: true})
+						if err != nil {
+							fatal(err)
 						}
-						id := c.Args().Get(0)
-						msService := api.MemberService{Client: client}
-						err := msService.Delete(id)
+						var mss []api.Membership
+						err = pager.Collect(&mss)
 						if err != nil {
-							log.Fatalln(err)
-						} else {
-							if !jsonFlag {
-								fmt.Println("Membership deleted.")
-							}
+							fatal(err)
 						}
-
-					},
+						if jsonFlag {
+							util.PrintJson(mss)
+							return
+						}
+						// Look up the room titles in one go.
+						roomService := api.RoomService{Client: client}
+						pager, err = roomService.List(ctx, "", "", "", 0, util.Paging{All: true})
+						if err != nil {
+							fatal(err)
+						}
+						var rooms []api.Room
+						err = pager.Collect(&rooms)
+						if err != nil {
+							fatal(err)
+						}
+						titles := make(map[string]string)
+						for _, room := range rooms {
+							titles[room.Id] = room.Title
+						}
+						for _, ms := range mss {
+							fmt.Printf("%s:\n", ms.Id)
+							fmt.Printf("   Room: %s (%s)\n", titles[ms.RoomId], ms.RoomId)
+							fmt.Printf("   Moderator: %t\n", ms.IsModerator)
+							fmt.Printf("   Hidden: %t\n", ms.IsRoomHidden)
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "delete membership",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli memberships delete <id>")
+						}
+						id := c.Args().Get(0)
+						msService := api.MemberService{Client: client}
+						err := msService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Membership deleted.")
+							}
+						}
+
+					},
+				},
+			},
+		},
+		{
+			Name:    "teams",
+			Aliases: []string{"t"},
+			Usage:   "operations on teams",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list all teams",
+					Flags:   pagingFlags(),
+					Action: func(c *cli.Context) {
+						teamService := api.TeamService{Client: client}
+						pager, err := teamService.List(ctx, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var teams []api.Team
+						err = printPages(pager, jsonFlag, &teams, func() {
+							for _, team := range teams {
+								fmt.Printf("%s: %s\n", team.Id, team.Name)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "create a new team",
+					Action: func(c *cli.Context) {
+						if c.NArg() < 1 {
+							log.Fatal("Usage: sparkcli teams create <name>")
+						}
+						name := strings.Join(c.Args(), " ")
+						teamService := api.TeamService{Client: client}
+						team, err := teamService.Create(ctx, name)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(team)
+							} else {
+								// Print just teamId, so can assign to env variable if desired.
+								fmt.Print(team.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get team details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli teams get <id>")
+						}
+						id := c.Args().Get(0)
+						teamService := api.TeamService{Client: client}
+						team, err := teamService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(team)
+							} else {
+								fmt.Printf("Id:      %s\n", team.Id)
+								fmt.Printf("Name:    %s\n", team.Name)
+								fmt.Printf("Creator: %s\n", team.CreatorId)
+								fmt.Printf("Created: %s\n", team.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "rename a team",
+					Action: func(c *cli.Context) {
+						if c.NArg() < 2 {
+							log.Fatal("Usage: sparkcli teams update <id> <name>")
+						}
+						id := c.Args().Get(0)
+						name := strings.Join(c.Args().Tail(), " ")
+						teamService := api.TeamService{Client: client}
+						team, err := teamService.Update(ctx, id, name)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(team)
+							} else {
+								fmt.Printf("Id:      %s\n", team.Id)
+								fmt.Printf("Name:    %s\n", team.Name)
+								fmt.Printf("Creator: %s\n", team.CreatorId)
+								fmt.Printf("Created: %s\n", team.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "delete a team",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli teams delete <id>")
+						}
+						id := c.Args().Get(0)
+						teamService := api.TeamService{Client: client}
+						err := teamService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Team deleted.")
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:    "team-memberships",
+			Aliases: []string{"tm"},
+			Usage:   "operations on team memberships",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list memberships of a team",
+					Flags:   pagingFlags(),
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli team-memberships list <teamId>")
+						}
+						teamId := c.Args().Get(0)
+						tmService := api.TeamMemberService{Client: client}
+						pager, err := tmService.List(ctx, teamId, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var tms []api.TeamMembership
+						err = printPages(pager, jsonFlag, &tms, func() {
+							for _, tm := range tms {
+								fmt.Printf("%s:\n", tm.Id)
+								fmt.Printf("   Name: %s\n", tm.PersonDisplayName)
+								fmt.Printf("   Email: %s\n", tm.PersonEmail)
+								fmt.Printf("   Moderator: %t\n", tm.IsModerator)
+								fmt.Printf("   Created: %s\n", tm.Created)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "add a person to a team",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "team, t",
+							Usage: "team to add person to",
+						},
+						cli.StringFlag{
+							Name:  "personid, p",
+							Usage: "id of person to add",
+						},
+						cli.StringFlag{
+							Name:  "email, e",
+							Usage: "email of person to add",
+						},
+						cli.BoolFlag{
+							Name:  "moderator, m",
+							Usage: "make the person a team moderator",
+						},
+					},
+					Action: func(c *cli.Context) {
+						teamId := c.String("team")
+						if teamId == "" {
+							log.Fatal("Usage: sparkcli team-memberships create -t <teamId> -e <email>|-p <personId> [-m]")
+						}
+						personId := c.String("personid")
+						personEmail := c.String("email")
+						moderator := c.Bool("moderator")
+						tmService := api.TeamMemberService{Client: client}
+						tm, err := tmService.Create(ctx, teamId, personId, personEmail, moderator)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(tm)
+							} else {
+								fmt.Printf("Id:        %s\n", tm.Id)
+								fmt.Printf("Name:      %s\n", tm.PersonDisplayName)
+								fmt.Printf("Email:     %s\n", tm.PersonEmail)
+								fmt.Printf("Team:      %s\n", tm.TeamId)
+								fmt.Printf("Moderator: %t\n", tm.IsModerator)
+								fmt.Printf("Created:   %s\n", tm.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get team membership details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli team-memberships get <id>")
+						}
+						id := c.Args().Get(0)
+						tmService := api.TeamMemberService{Client: client}
+						tm, err := tmService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(tm)
+							} else {
+								fmt.Printf("Id:        %s\n", tm.Id)
+								fmt.Printf("Name:      %s\n", tm.PersonDisplayName)
+								fmt.Printf("Email:     %s\n", tm.PersonEmail)
+								fmt.Printf("Team:      %s\n", tm.TeamId)
+								fmt.Printf("Moderator: %t\n", tm.IsModerator)
+								fmt.Printf("Created:   %s\n", tm.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "update team membership",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "moderator, m",
+							Usage: "set moderator role for the team membership",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 || !c.IsSet("moderator") {
+							log.Fatal("Usage: sparkcli team-memberships update -moderator=true|false <id>")
+						}
+						id := c.Args().Get(0)
+						moderator := c.Bool("moderator")
+						tmService := api.TeamMemberService{Client: client}
+						tm, err := tmService.Update(ctx, id, moderator)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(tm)
+							} else {
+								fmt.Printf("Id:        %s\n", tm.Id)
+								fmt.Printf("Name:      %s\n", tm.PersonDisplayName)
+								fmt.Printf("Email:     %s\n", tm.PersonEmail)
+								fmt.Printf("Team:      %s\n", tm.TeamId)
+								fmt.Printf("Moderator: %t\n", tm.IsModerator)
+								fmt.Printf("Created:   %s\n", tm.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "remove a person from a team",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli team-memberships delete <id>")
+						}
+						id := c.Args().Get(0)
+						tmService := api.TeamMemberService{Client: client}
+						err := tmService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Team membership deleted.")
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:    "webhooks",
+			Aliases: []string{"w"},
+			Usage:   "operations on webhooks",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list all webhooks",
+					Flags:   pagingFlags(),
+					Action: func(c *cli.Context) {
+						hookService := api.WebhookService{Client: client}
+						pager, err := hookService.List(ctx, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var hooks []api.Webhook
+						err = printPages(pager, jsonFlag, &hooks, func() {
+							for _, hook := range hooks {
+								fmt.Printf("%s:\n", hook.Id)
+								fmt.Printf("   Name:     %s\n", hook.Name)
+								fmt.Printf("   Target:   %s\n", hook.TargetUrl)
+								fmt.Printf("   Resource: %s\n", hook.Resource)
+								fmt.Printf("   Event:    %s\n", hook.Event)
+								fmt.Printf("   Filter:   %s\n", hook.Filter)
+								fmt.Printf("   Status:   %s\n", hook.Status)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "register a new webhook",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "name, n",
+							Usage: "name of the webhook",
+						},
+						cli.StringFlag{
+							Name:  "target, t",
+							Usage: "URL that receives the POST requests",
+						},
+						cli.StringFlag{
+							Name:  "resource, r",
+							Usage: "resource to watch (e.g. messages, memberships, rooms or all)",
+						},
+						cli.StringFlag{
+							Name:  "event, e",
+							Usage: "event to watch (e.g. created, updated, deleted or all)",
+						},
+						cli.StringFlag{
+							Name:  "filter, f",
+							Usage: "filter on the resource (e.g. roomId=<roomId>)",
+						},
+						cli.StringFlag{
+							Name:  "secret, s",
+							Usage: "secret used to sign the webhook payload",
+						},
+					},
+					Action: func(c *cli.Context) {
+						name := c.String("name")
+						target := c.String("target")
+						resource := c.String("resource")
+						event := c.String("event")
+						if name == "" || target == "" || resource == "" || event == "" {
+							log.Fatal("Usage: sparkcli webhooks create -n <name> -t <targetUrl> -r <resource> -e <event> [-f <filter>] [-s <secret>]")
+						}
+						hookService := api.WebhookService{Client: client}
+						hook, err := hookService.Create(ctx, name, target, resource, event, c.String("filter"), c.String("secret"))
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(hook)
+							} else {
+								// Print just the webhook id, so can assign to env variable if desired.
+								fmt.Print(hook.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get webhook details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli webhooks get <id>")
+						}
+						id := c.Args().Get(0)
+						hookService := api.WebhookService{Client: client}
+						hook, err := hookService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(hook)
+							} else {
+								fmt.Printf("Id:       %s\n", hook.Id)
+								fmt.Printf("Name:     %s\n", hook.Name)
+								fmt.Printf("Target:   %s\n", hook.TargetUrl)
+								fmt.Printf("Resource: %s\n", hook.Resource)
+								fmt.Printf("Event:    %s\n", hook.Event)
+								fmt.Printf("Filter:   %s\n", hook.Filter)
+								fmt.Printf("Status:   %s\n", hook.Status)
+								fmt.Printf("Created:  %s\n", hook.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "update a webhook",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "name, n",
+							Usage: "new name of the webhook",
+						},
+						cli.StringFlag{
+							Name:  "target, t",
+							Usage: "new URL that receives the POST requests",
+						},
+						cli.StringFlag{
+							Name:  "secret, s",
+							Usage: "new secret used to sign the webhook payload",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli webhooks update [-n <name>] [-t <targetUrl>] [-s <secret>] <id>")
+						}
+						id := c.Args().Get(0)
+						hookService := api.WebhookService{Client: client}
+						current, err := hookService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						name := current.Name
+						if c.IsSet("name") {
+							name = c.String("name")
+						}
+						target := current.TargetUrl
+						if c.IsSet("target") {
+							target = c.String("target")
+						}
+						secret := current.Secret
+						if c.IsSet("secret") {
+							secret = c.String("secret")
+						}
+						hook, err := hookService.Update(ctx, id, name, target, secret)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(hook)
+							} else {
+								fmt.Printf("Id:       %s\n", hook.Id)
+								fmt.Printf("Name:     %s\n", hook.Name)
+								fmt.Printf("Target:   %s\n", hook.TargetUrl)
+								fmt.Printf("Resource: %s\n", hook.Resource)
+								fmt.Printf("Event:    %s\n", hook.Event)
+								fmt.Printf("Filter:   %s\n", hook.Filter)
+								fmt.Printf("Status:   %s\n", hook.Status)
+								fmt.Printf("Created:  %s\n", hook.Created)
+							}
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "delete a webhook",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli webhooks delete <id>")
+						}
+						id := c.Args().Get(0)
+						hookService := api.WebhookService{Client: client}
+						err := hookService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Webhook deleted.")
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:    "attachment-actions",
+			Aliases: []string{"aa"},
+			Usage:   "operations on attachment actions (submitted cards)",
+			Subcommands: []cli.Command{
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get the inputs submitted for a card",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli attachment-actions get <id>")
+						}
+						id := c.Args().Get(0)
+						actionService := api.AttachmentActionService{Client: client}
+						action, err := actionService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(action)
+							} else {
+								fmt.Printf("Id:        %s\n", action.Id)
+								fmt.Printf("Type:      %s\n", action.Type)
+								fmt.Printf("MessageId: %s\n", action.MessageId)
+								fmt.Printf("PersonId:  %s\n", action.PersonId)
+								fmt.Printf("RoomId:    %s\n", action.RoomId)
+								fmt.Printf("Created:   %s\n", action.Created)
+								fmt.Println("Inputs:")
+								keys := make([]string, 0, len(action.Inputs))
+								for key := range action.Inputs {
+									keys = append(keys, key)
+								}
+								sort.Strings(keys)
+								for _, key := range keys {
+									fmt.Printf("   %s: %v\n", key, action.Inputs[key])
+								}
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:    "orgs",
+			Aliases: []string{"o"},
+			Usage:   "operations on organizations",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list organizations",
+					Flags:   pagingFlags(),
+					Action: func(c *cli.Context) {
+						orgService := api.OrganizationService{Client: client}
+						pager, err := orgService.List(ctx, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var orgs []api.Organization
+						err = printPages(pager, jsonFlag, &orgs, func() {
+							for _, org := range orgs {
+								fmt.Printf("%s: %s\n", org.Id, org.DisplayName)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get organization details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli orgs get <id>")
+						}
+						id := c.Args().Get(0)
+						orgService := api.OrganizationService{Client: client}
+						org, err := orgService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(org)
+							} else {
+								fmt.Printf("Id:      %s\n", org.Id)
+								fmt.Printf("Name:    %s\n", org.DisplayName)
+								fmt.Printf("Created: %s\n", org.Created)
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:  "licenses",
+			Usage: "operations on licenses",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list licenses",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "org, o",
+							Usage: "organization to list licenses for",
+						},
+					}, pagingFlags()...),
+					Action: func(c *cli.Context) {
+						licenseService := api.LicenseService{Client: client}
+						pager, err := licenseService.List(ctx, c.String("org"), paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var licenses []api.License
+						err = printPages(pager, jsonFlag, &licenses, func() {
+							for _, license := range licenses {
+								fmt.Printf("%s: %s (%d/%d)\n", license.Id, license.Name, license.ConsumedUnits, license.TotalUnits)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get license details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli licenses get <id>")
+						}
+						id := c.Args().Get(0)
+						licenseService := api.LicenseService{Client: client}
+						license, err := licenseService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(license)
+							} else {
+								fmt.Printf("Id:       %s\n", license.Id)
+								fmt.Printf("Name:     %s\n", license.Name)
+								fmt.Printf("Total:    %d\n", license.TotalUnits)
+								fmt.Printf("Consumed: %d\n", license.ConsumedUnits)
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:  "roles",
+			Usage: "operations on roles",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list roles",
+					Flags:   pagingFlags(),
+					Action: func(c *cli.Context) {
+						roleService := api.RoleService{Client: client}
+						pager, err := roleService.List(ctx, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var roles []api.Role
+						err = printPages(pager, jsonFlag, &roles, func() {
+							for _, role := range roles {
+								fmt.Printf("%s: %s\n", role.Id, role.Name)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get role details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli roles get <id>")
+						}
+						id := c.Args().Get(0)
+						roleService := api.RoleService{Client: client}
+						role, err := roleService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(role)
+							} else {
+								fmt.Printf("Id:   %s\n", role.Id)
+								fmt.Printf("Name: %s\n", role.Name)
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:    "events",
+			Aliases: []string{"e"},
+			Usage:   "browse events (compliance officers only)",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list events",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "resource, r",
+							Usage: "only events for this resource (e.g. messages, memberships)",
+						},
+						cli.StringFlag{
+							Name:  "type, t",
+							Usage: "only events of this type (created, updated or deleted)",
+						},
+						cli.StringFlag{
+							Name:  "actor, a",
+							Usage: "only events caused by this person id",
+						},
+						cli.StringFlag{
+							Name:  "from, f",
+							Usage: "only events after this time (ISO 8601, or a duration ago like 7d or 12h)",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "only events before this time (ISO 8601, or a duration ago like 7d or 12h)",
+						},
+						cli.IntFlag{
+							Name:  "max, m",
+							Usage: "maximum number of events per page",
+						},
+						cli.BoolFlag{
+							Name:  "lines",
+							Usage: "print one JSON object per line",
+						},
+					}, pagingFlags()...),
+					Action: func(c *cli.Context) {
+						from, err := parseTime(c.String("from"))
+						if err != nil {
+							fatal(err)
+						}
+						to, err := parseTime(c.String("to"))
+						if err != nil {
+							fatal(err)
+						}
+						filter := api.EventFilter{
+							Resource: c.String("resource"),
+							Type:     c.String("type"),
+							ActorId:  c.String("actor"),
+							From:     from,
+							To:       to,
+							Max:      c.Int("max"),
+						}
+						eventService := api.EventService{Client: client}
+						pager, err := eventService.List(ctx, filter, paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var events []api.Event
+						err = printPages(pager, jsonFlag && !c.Bool("lines"), &events, func() {
+							for _, event := range events {
+								if c.Bool("lines") {
+									util.PrintJsonLine(event)
+								} else {
+									fmt.Printf("[%v] %v %v %v by %v\n", event.Created, event.Resource, event.Type, event.Id, event.ActorId)
+								}
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get event details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli events get <id>")
+						}
+						id := c.Args().Get(0)
+						eventService := api.EventService{Client: client}
+						event, err := eventService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(event)
+							} else {
+								fmt.Printf("Id:       %s\n", event.Id)
+								fmt.Printf("Resource: %s\n", event.Resource)
+								fmt.Printf("Type:     %s\n", event.Type)
+								fmt.Printf("Actor:    %s\n", event.ActorId)
+								fmt.Printf("Org:      %s\n", event.OrgId)
+								fmt.Printf("Created:  %s\n", event.Created)
+								fmt.Printf("Data:     %s\n", event.Data)
+							}
+						}
+					},
+				},
+			},
+		},
+		{
+			Name:  "meetings",
+			Usage: "operations on scheduled meetings",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list scheduled meetings",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "from, f",
+							Usage: "only meetings starting after this time (ISO 8601)",
+						},
+						cli.StringFlag{
+							Name:  "to, t",
+							Usage: "only meetings starting before this time (ISO 8601)",
+						},
+						cli.IntFlag{
+							Name:  "max, m",
+							Usage: "maximum number of meetings per page",
+						},
+					}, pagingFlags()...),
+					Action: func(c *cli.Context) {
+						meetingService := api.MeetingService{Client: client}
+						pager, err := meetingService.List(ctx, c.String("from"), c.String("to"), c.Int("max"), paging(c))
+						if err != nil {
+							fatal(err)
+						}
+						var meetings []api.Meeting
+						err = printPages(pager, jsonFlag, &meetings, func() {
+							for _, meeting := range meetings {
+								fmt.Printf("%s: %s (%s - %s)\n", meeting.Id, meeting.Title, meeting.Start, meeting.End)
+							}
+						})
+						if err != nil {
+							fatal(err)
+						}
+					},
+				},
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "schedule a meeting",
+					Flags:   meetingFlags(),
+					Action: func(c *cli.Context) {
+						if c.String("title") == "" || c.String("start") == "" || c.String("end") == "" {
+							log.Fatal("Usage: sparkcli meetings create -title <title> -start <time> -end <time> [-timezone <tz>] [-invitee <email>] [-password <password>]")
+						}
+						var meeting api.Meeting
+						updateMeeting(c, &meeting)
+						for _, email := range c.StringSlice("invitee") {
+							meeting.Invitees = append(meeting.Invitees, api.Invitee{Email: email})
+						}
+						meetingService := api.MeetingService{Client: client}
+						created, err := meetingService.Create(ctx, meeting)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(created)
+							} else {
+								// Print just the id, so can assign to env variable if desired.
+								fmt.Print(created.Id)
+							}
+						}
+					},
+				},
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get meeting details",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli meetings get <id>")
+						}
+						id := c.Args().Get(0)
+						meetingService := api.MeetingService{Client: client}
+						meeting, err := meetingService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(meeting)
+							} else {
+								printMeeting(meeting)
+							}
+						}
+					},
+				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "change a scheduled meeting",
+					Flags:   meetingFlags(),
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli meetings update [-title <title>] [-start <time>] [-end <time>] [...] <id>")
+						}
+						id := c.Args().Get(0)
+						meetingService := api.MeetingService{Client: client}
+						meeting, err := meetingService.Get(ctx, id)
+						if err != nil {
+							fatal(err)
+						}
+						updateMeeting(c, meeting)
+						updated, err := meetingService.Update(ctx, id, *meeting)
+						if err != nil {
+							fatal(err)
+						} else {
+							if jsonFlag {
+								util.PrintJson(updated)
+							} else {
+								printMeeting(updated)
+							}
+						}
+					},
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "cancel a scheduled meeting",
+					Action: func(c *cli.Context) {
+						if c.NArg() != 1 {
+							log.Fatal("Usage: sparkcli meetings delete <id>")
+						}
+						id := c.Args().Get(0)
+						meetingService := api.MeetingService{Client: client}
+						err := meetingService.Delete(ctx, id)
+						if err != nil {
+							fatal(err)
+						} else {
+							if !jsonFlag {
+								fmt.Println("Meeting deleted.")
+							}
+						}
+					},
 				},
 			},
 		},
 	}
 	app.Run(os.Args)
 }
+
+// printThreaded prints msgs with the replies indented under their parent.
+// msgs are expected newest first, the way the API returns them.  Replies are
+// printed oldest first, so a thread reads top to bottom.
+func printThreaded(msgs []api.Message) {
+	present := make(map[string]bool)
+	for _, msg := range msgs {
+		present[msg.Id] = true
+	}
+	replies := make(map[string][]api.Message)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.ParentId != "" && present[msg.ParentId] {
+			replies[msg.ParentId] = append(replies[msg.ParentId], msg)
+		}
+	}
+	for _, msg := range msgs {
+		if msg.ParentId != "" {
+			if present[msg.ParentId] {
+				continue // printed with its parent
+			}
+			// parent is older than what we retrieved.
+			fmt.Printf("[%v] %v (reply to %v): %v\n", msg.Created, msg.PersonEmail, msg.ParentId, msgBody(msg))
+			continue
+		}
+		fmt.Printf("[%v] %v: %v\n", msg.Created, msg.PersonEmail, msgBody(msg))
+		for _, reply := range replies[msg.Id] {
+			fmt.Printf("    [%v] %v: %v\n", reply.Created, reply.PersonEmail, msgBody(reply))
+		}
+	}
+}
+
+// personFlags are the options to set the details of a person.
+func personFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "email, e",
+			Usage: "email address; can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "name, n",
+			Usage: "display name",
+		},
+		cli.StringFlag{
+			Name:  "first",
+			Usage: "first name",
+		},
+		cli.StringFlag{
+			Name:  "last",
+			Usage: "last name",
+		},
+		cli.StringFlag{
+			Name:  "nick",
+			Usage: "nickname",
+		},
+		cli.StringFlag{
+			Name:  "avatar",
+			Usage: "url of the avatar",
+		},
+		cli.StringFlag{
+			Name:  "org, o",
+			Usage: "organization id",
+		},
+		cli.StringSliceFlag{
+			Name:  "role",
+			Usage: "role id; can be repeated",
+		},
+		cli.StringSliceFlag{
+			Name:  "license",
+			Usage: "license id; can be repeated",
+		},
+	}
+}
+
+// updatePerson copies the personFlags that were set on c into person.
+func updatePerson(c *cli.Context, person *api.People) {
+	if c.IsSet("email") {
+		person.Emails = c.StringSlice("email")
+	}
+	if c.IsSet("name") {
+		person.DisplayName = c.String("name")
+	}
+	if c.IsSet("first") {
+		person.FirstName = c.String("first")
+	}
+	if c.IsSet("last") {
+		person.LastName = c.String("last")
+	}
+	if c.IsSet("nick") {
+		person.NickName = c.String("nick")
+	}
+	if c.IsSet("avatar") {
+		person.Avatar = c.String("avatar")
+	}
+	if c.IsSet("org") {
+		person.OrgId = c.String("org")
+	}
+	if c.IsSet("role") {
+		person.Roles = nonEmpty(c.StringSlice("role"))
+	}
+	if c.IsSet("license") {
+		person.Licenses = nonEmpty(c.StringSlice("license"))
+	}
+}
+
+// nonEmpty returns values without the empty ones, so e.g. -role "" clears the
+// roles of a person.
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// moderateRoom applies change to the room given as the only argument of c
+// (or the default room) and saves it.  The caller has to be a moderator of
+// the room, except to lock a room that isn't locked yet: it has no
+// moderators, so any member can lock it.  Exits on any error.
+func moderateRoom(ctx context.Context, c *cli.Context, client *util.Client, command string, change func(room *api.Room)) *api.Room {
+	if c.NArg() > 1 {
+		log.Fatalf("Usage: sparkcli rooms %s <id>", command)
+	}
+	id := c.Args().Get(0)
+	if id == "" || id == "-" {
+		id = util.GetConfiguration().DefaultRoomId
+		if id == "" {
+			log.Fatalf("Usage: sparkcli rooms %s <id> (no default room configured)", command)
+		}
+	}
+	roomService := api.RoomService{Client: client}
+	room, err := roomService.Get(ctx, id)
+	if err != nil {
+		fatal(err)
+	}
+	peopleService := api.PeopleService{Client: client}
+	me, err := peopleService.GetMe(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	memberService := api.MemberService{Client: client}
+	pager, err := memberService.List(ctx, id, me.Id, "", util.Paging{})
+	if err != nil {
+		fatal(err)
+	}
+	var mss []api.Membership
+	err = pager.Next(&mss)
+	if err != nil {
+		fatal(err)
+	}
+	if len(mss) == 0 {
+		log.Fatal("You need to be a member of the room to do this.")
+	}
+	if !mss[0].IsModerator && (room.IsLocked || command != "lock") {
+		log.Fatal("You need to be a moderator of the room to do this.")
+	}
+	change(room)
+	room, err = roomService.Update(ctx, id, *room)
+	if err != nil {
+		fatal(err)
+	}
+	return room
+}
+
+// Exit codes for failed requests, so scripts can tell failures apart.  Other
+// errors, like wrong usage, exit with 1.
+const (
+	exitAuth        = 3 // 401 or 403: not logged in or not allowed
+	exitNotFound    = 4 // 404
+	exitRateLimited = 5 // 429, still rate limited after retrying
+	exitServer      = 6 // 5xx
+	exitTimeout     = 7 // -timeout expired
+	exitInterrupted = 130
+)
+
+// interruptGrace is how long a command gets to stop after Ctrl-C.
+const interruptGrace = time.Second
+
+// fatal logs err and exits with a code that matches the kind of error.
+func fatal(err error) {
+	log.Println(err)
+	os.Exit(exitCode(err))
+}
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return exitTimeout
+	}
+	var apiErr *util.APIError
+	if !errors.As(err, &apiErr) {
+		return 1
+	}
+	switch code := apiErr.StatusCode; {
+	case code == 401 || code == 403:
+		return exitAuth
+	case code == 404:
+		return exitNotFound
+	case code == 429:
+		return exitRateLimited
+	case code >= 500:
+		return exitServer
+	}
+	return 1
+}
+
+// pagingFlags are the options of list commands to retrieve more than the
+// first page of results.
+func pagingFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "retrieve all pages of results",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Usage: "retrieve pages until there are this many results",
+		},
+	}
+}
+
+// paging returns the util.Paging for the pagingFlags set on c.
+func paging(c *cli.Context) util.Paging {
+	return util.Paging{All: c.Bool("all"), Limit: c.Int("limit")}
+}
+
+// printPages prints the results of pager as each page arrives.  With
+// jsonFlag they're printed as a single JSON array.  Otherwise every page is
+// decoded into items, a pointer to a slice, and print is called to print it.
+func printPages(pager *util.Pager, jsonFlag bool, items interface{}, print func()) error {
+	if jsonFlag {
+		return util.PrintJsonPages(pager)
+	}
+	for pager.More() {
+		err := pager.Next(items)
+		if err != nil {
+			return err
+		}
+		print()
+	}
+	return nil
+}
+
+// meetingFlags are the options to set the details of a meeting.
+func meetingFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "title, t",
+			Usage: "title of the meeting",
+		},
+		cli.StringFlag{
+			Name:  "agenda, a",
+			Usage: "agenda of the meeting",
+		},
+		cli.StringFlag{
+			Name:  "start, s",
+			Usage: "start time (ISO 8601, e.g. 2016-05-01T14:00:00)",
+		},
+		cli.StringFlag{
+			Name:  "end, e",
+			Usage: "end time (ISO 8601)",
+		},
+		cli.StringFlag{
+			Name:  "timezone, z",
+			Usage: "timezone of start and end (e.g. Europe/Brussels)",
+		},
+		cli.StringFlag{
+			Name:  "password, p",
+			Usage: "meeting password",
+		},
+		cli.StringSliceFlag{
+			Name:  "invitee, i",
+			Usage: "email of a person to invite; can be repeated (create only)",
+		},
+	}
+}
+
+// updateMeeting copies the meetingFlags that were set on c into meeting.
+// Invitees are handled separately, since they can only be set on create.
+func updateMeeting(c *cli.Context, meeting *api.Meeting) {
+	if c.IsSet("title") {
+		meeting.Title = c.String("title")
+	}
+	if c.IsSet("agenda") {
+		meeting.Agenda = c.String("agenda")
+	}
+	if c.IsSet("start") {
+		meeting.Start = c.String("start")
+	}
+	if c.IsSet("end") {
+		meeting.End = c.String("end")
+	}
+	if c.IsSet("timezone") {
+		meeting.Timezone = c.String("timezone")
+	}
+	if c.IsSet("password") {
+		meeting.Password = c.String("password")
+	}
+}
+
+// printMeeting prints the details of meeting.
+func printMeeting(meeting *api.Meeting) {
+	fmt.Printf("Id:       %s\n", meeting.Id)
+	fmt.Printf("Title:    %s\n", meeting.Title)
+	fmt.Printf("Agenda:   %s\n", meeting.Agenda)
+	fmt.Printf("Start:    %s\n", meeting.Start)
+	fmt.Printf("End:      %s\n", meeting.End)
+	fmt.Printf("Timezone: %s\n", meeting.Timezone)
+	fmt.Printf("Host:     %s\n", meeting.HostEmail)
+	fmt.Printf("State:    %s\n", meeting.State)
+	fmt.Printf("Number:   %s\n", meeting.MeetingNumber)
+	fmt.Printf("Link:     %s\n", meeting.WebLink)
+	fmt.Printf("Sip:      %s\n", meeting.SipAddress)
+}
+
+// expandPerson replaces the role and license ids of person with their names.
+func expandPerson(ctx context.Context, client *util.Client, person *api.People) error {
+	roleService := api.RoleService{Client: client}
+	for i, id := range person.Roles {
+		role, err := roleService.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		person.Roles[i] = role.Name
+	}
+	licenseService := api.LicenseService{Client: client}
+	for i, id := range person.Licenses {
+		license, err := licenseService.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		person.Licenses[i] = license.Name
+	}
+	return nil
+}
+
+// printPerson prints the details of person, except the id, with each line
+// prefixed by indent.  Details that aren't set are skipped.
+func printPerson(person *api.People, indent string) {
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Name:", person.DisplayName},
+		{"Nickname:", person.NickName},
+		{"First Name:", person.FirstName},
+		{"Last Name:", person.LastName},
+		{"Email:", strings.Join(person.Emails, ", ")},
+		{"Avatar:", person.Avatar},
+		{"Org:", person.OrgId},
+		{"Roles:", strings.Join(person.Roles, ", ")},
+		{"Licenses:", strings.Join(person.Licenses, ", ")},
+		{"Type:", person.Type},
+		{"Status:", person.Status},
+		{"Created:", person.Created},
+		{"Last Activity:", person.LastActivity},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Printf("%s%-14s %s\n", indent, field.label, field.value)
+		}
+	}
+}
+
+// withMentions prepends the markup that mentions each of people to body.
+// people are email addresses, person ids or "all".
+func withMentions(ctx context.Context, client *util.Client, people []string, body string) (string, error) {
+	if len(people) == 0 {
+		return body, nil
+	}
+	peopleService := api.PeopleService{Client: client}
+	var mentions []string
+	for _, person := range people {
+		mention, err := peopleService.Mention(ctx, person)
+		if err != nil {
+			return "", err
+		}
+		mentions = append(mentions, mention)
+	}
+	return strings.Join(mentions, " ") + " " + body, nil
+}
+
+// parseTime returns value as an ISO 8601 timestamp.  value is either a
+// timestamp already, or a duration ago such as 12h or 7d (days aren't
+// supported by time.ParseDuration).
+func parseTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil {
+			return time.Now().UTC().AddDate(0, 0, -days).Format(time.RFC3339), nil
+		}
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return time.Now().UTC().Add(-ago).Format(time.RFC3339), nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", fmt.Errorf("Invalid time %q: use ISO 8601 or a duration like 7d", value)
+	}
+	return value, nil
+}
+
+// msgBody returns the markdown of msg when present, otherwise its plain text.
+func msgBody(msg api.Message) string {
+	if msg.Markdown != "" {
+		return msg.Markdown
+	}
+	return msg.Text
+}
+
+// readBody returns the message body from args when present.  Otherwise it is
+// read from file, or from stdin when no file is given (or file is "-").
+func readBody(args []string, file string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	var body []byte
+	var err error
+	if file == "" || file == "-" {
+		body, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		body, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(body), "\n"), nil
+}
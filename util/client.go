@@ -2,8 +2,10 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -12,6 +14,12 @@ import (
 	"mime/multipart"
 	"path/filepath"
 	"io"
+	"mime"
+	"path"
+	"strings"
+	"math/rand"
+	"strconv"
+	"time"
 )
 
 const (
@@ -24,14 +32,28 @@ type Client struct {
 	userAgent string
 
 	config *Configuration
+
+	// sleep waits between retries; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
-func NewClient(config *Configuration) *Client {
-	c := &Client{client: http.DefaultClient, userAgent: userAgent, config: config}
-	return c
+// NewClient creates a Client that sends requests with the proxy, TLS and
+// timeout settings of config.  There's no overall timeout, so large
+// downloads still work; use the context of a request to limit its total time.
+// Returns an error when one of these settings is invalid.
+func NewClient(config *Configuration) (*Client, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{client: &http.Client{Transport: transport}, userAgent: userAgent, config: config, sleep: sleep}
+	return c, nil
 }
 
-func (c *Client) NewRequest(method string, path string, body interface{}) (*http.Request, error) {
+// NewRequest creates a request for path, relative to the base url, with body
+// sent as JSON.  The request is cancelled when ctx is done, including any
+// retries of it.
+func (c *Client) NewRequest(ctx context.Context, method string, path string, body interface{}) (*http.Request, error) {
 	// concat base url and request url
 	reqUrl, err := url.Parse(c.config.BaseUrl + path)
 	if err != nil {
@@ -48,14 +70,14 @@ func (c *Client) NewRequest(method string, path string, body interface{}) (*http
 		bodyBuffer = bytes.NewBuffer(bodyJson)
 		log.Printf("Sending: %s", bodyBuffer)
 		// Create request with body
-		req, err = http.NewRequest(method, reqUrl.String(), bodyBuffer)
+		req, err = http.NewRequestWithContext(ctx, method, reqUrl.String(), bodyBuffer)
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("Content-Type", "application/json")
 	} else {
 		// Create request without body
-		req, err = http.NewRequest(method, reqUrl.String(), nil)
+		req, err = http.NewRequestWithContext(ctx, method, reqUrl.String(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -65,7 +87,9 @@ func (c *Client) NewRequest(method string, path string, body interface{}) (*http
 	return req, nil
 }
 
-func (c *Client) NewFileUploadRequest(path string, roomId string, fileLocation string) (*http.Request, error) {
+// NewFileUploadRequest creates a multipart POST request that uploads the file
+// at fileLocation, together with the given form fields (e.g. roomId).
+func (c *Client) NewFileUploadRequest(ctx context.Context, path string, fields map[string]string, fileLocation string) (*http.Request, error) {
 	// concat base url and request url
 	reqUrl, err := url.Parse(c.config.BaseUrl + path)
 	if err != nil {
@@ -85,14 +109,25 @@ func (c *Client) NewFileUploadRequest(path string, roomId string, fileLocation s
 		return nil, err
 	}
 	_, err = io.Copy(part, file)
-	_ = writer.WriteField("roomId", roomId)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		err = writer.WriteField(name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	err = writer.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", reqUrl.String(), body)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqUrl.String(), body)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// Add other headers (that apply to all requests)
@@ -100,81 +135,321 @@ func (c *Client) NewFileUploadRequest(path string, roomId string, fileLocation s
 	return req, nil
 }
 
-func (c *Client) NewGetRequest(path string) (*http.Request, error) {
-	return c.NewRequest("GET", path, nil)
+func (c *Client) NewGetRequest(ctx context.Context, path string) (*http.Request, error) {
+	return c.NewRequest(ctx, "GET", path, nil)
 }
 
-func (c *Client) NewPostRequest(path string, body interface{}) (*http.Request, error) {
-	return c.NewRequest("POST", path, body)
+func (c *Client) NewPostRequest(ctx context.Context, path string, body interface{}) (*http.Request, error) {
+	return c.NewRequest(ctx, "POST", path, body)
 }
 
-func (c *Client) NewPutRequest(path string, body interface{}) (*http.Request, error) {
-	return c.NewRequest("PUT", path, body)
+func (c *Client) NewPutRequest(ctx context.Context, path string, body interface{}) (*http.Request, error) {
+	return c.NewRequest(ctx, "PUT", path, body)
 }
 
-func (c *Client) NewDeleteRequest(path string) (*http.Request, error) {
-	return c.NewRequest("DELETE", path, nil)
+func (c *Client) NewDeleteRequest(ctx context.Context, path string) (*http.Request, error) {
+	return c.NewRequest(ctx, "DELETE", path, nil)
 }
 
-func (c *Client) NewFilePostRequest(path string, roomId string, fileLocation string) (*http.Request, error) {
-	return c.NewFileUploadRequest(path, roomId, fileLocation)
+func (c *Client) NewFilePostRequest(ctx context.Context, path string, roomId string, fileLocation string) (*http.Request, error) {
+	return c.NewFileUploadRequest(ctx, path, map[string]string{"roomId": roomId}, fileLocation)
 }
 
+// Do executes req and decodes the JSON response into to, if not nil.  It
+// stops, with the error of the context, when the context of req is done.
 func (c *Client) Do(req *http.Request, to interface{}) (*http.Response, error) {
-	var res *http.Response
-	res, err := c.client.Do(req)
-
+	res, err := c.send(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	// If 401, let's try to refresh tokens and try again.
-	if res.StatusCode == 401 {
-		login := Login{config: c.config, client: c}
-		login.RefreshToken()
-		// Update the request with new AccessToken.
-		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
-
-		res, err = c.client.Do(req)
+	if to != nil {
+		decoder := json.NewDecoder(res.Body)
+		err = decoder.Decode(&to)
 		if err != nil {
 			return nil, err
 		}
-		defer res.Body.Close()
 	}
-	err = checkStatusOk(res)
+	return res, nil
+}
+
+// NewContentRequest creates a GET request for a url returned by the API (e.g.
+// a message attachment or the next page of a list).  Unlike the other
+// requests, contentUrl is absolute.
+func (c *Client) NewContentRequest(ctx context.Context, contentUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", contentUrl, nil)
 	if err != nil {
-		log.Printf("Status: %s", res.Status)
 		return nil, err
 	}
-	if to != nil {
-		decoder := json.NewDecoder(res.Body)
-		err = decoder.Decode(&to)
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	return req, nil
+}
+
+// Download executes req and saves the response body in dir.  The file name is
+// taken from the Content-Disposition header, or from the request url if that
+// header is missing.  An existing file is never overwritten; a number is
+// added to the name instead.  Returns the path of the saved file.
+func (c *Client) Download(req *http.Request, dir string) (string, error) {
+	res, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	name := contentFilename(res.Header.Get("Content-Disposition"), req.URL.Path)
+	file, err := createUnique(dir, name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, res.Body)
+	if err != nil {
+		// Don't leave a partial file behind, e.g. when cancelled.
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// send executes req and returns the response with an unread body.  The caller
+// must close the body.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		res, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		// If 401, let's try to refresh tokens and try again.
+		if res.StatusCode == 401 {
+			res.Body.Close()
+			login := Login{config: c.config, client: c}
+			login.RefreshToken(req.Context())
+			// Update the request with new AccessToken.
+			req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+			res, err = c.client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if wait, ok := c.retryWait(req, res, attempt, time.Since(start)); ok {
+			res.Body.Close()
+			Debugf("%s %s: %s, retry %d of %d in %s", req.Method, req.URL.Path,
+				res.Status, attempt, c.config.RetryAttempts-1, wait)
+			if err := c.sleep(req.Context(), wait); err != nil {
+				return nil, err
+			}
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = checkStatusOk(res)
 		if err != nil {
+			res.Body.Close()
 			return nil, err
 		}
+		return res, nil
 	}
-	return res, nil
 }
 
-// error if status code is not in 2XX range
+const (
+	// retryBase is the backoff before the first retry, doubled on every next one.
+	retryBase = time.Second
+	// retryCap is the longest backoff between two attempts.
+	retryCap = 30 * time.Second
+)
+
+// retryWait returns how long to wait before sending req again, after it got
+// res on the given attempt.  It returns false if req shouldn't be retried:
+// the status isn't transient, attempts or time are used up, or the body
+// can't be sent again.  A 429 means the request wasn't processed, so it's
+// retried for any method; 502, 503 and 504 only for idempotent ones.
+func (c *Client) retryWait(req *http.Request, res *http.Response, attempt int, elapsed time.Duration) (time.Duration, bool) {
+	switch res.StatusCode {
+	case 429:
+	case 502, 503, 504:
+		if !idempotent(req.Method) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if attempt >= c.config.RetryAttempts {
+		return 0, false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return 0, false
+	}
+	wait, ok := retryAfter(res.Header.Get("Retry-After"))
+	if !ok {
+		wait = backoff(attempt)
+	}
+	if elapsed+wait > time.Duration(c.config.RetryMaxTime)*time.Second {
+		return 0, false
+	}
+	return wait, true
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idempotent reports whether sending a request with method twice has the
+// same effect as sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before retry number attempt: exponential, capped
+// at retryCap, with the upper half randomized so clients don't retry in step.
+func backoff(attempt int) time.Duration {
+	d := retryBase << uint(attempt-1)
+	if d > retryCap || d <= 0 {
+		d = retryCap
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(time.Now()); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// rewind resets the body of req so it can be sent again.
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// contentFilename returns the file name from a Content-Disposition header,
+// falling back to the last element of urlPath.  Any directories are stripped
+// so the name can't point outside the download directory.
+func contentFilename(disposition string, urlPath string) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(disposition); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = path.Base(urlPath)
+	}
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "." || name == string(os.PathSeparator) {
+		name = "download"
+	}
+	return name
+}
+
+// createUnique creates a new file called name in dir.  If that file already
+// exists, " (1)", " (2)", ... is added before the extension until the name is
+// free.
+func createUnique(dir string, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		file, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return file, err
+	}
+}
+
+// APIError is an error response from the Cisco Spark service, e.g.
+//
+//	{
+//		"message": "Failed to create room.",
+//		"errors": [
+//			{
+//				"description": "Failed to create room."
+//			}
+//		],
+//		"trackingId": "NA_f6e19aac-3a72-46d2-88ec-643f4d12fcbd"
+//	}
+//
+// Use IsStatus to check for a specific status code.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Status     string `json:"-"`
+	Message    string `json:"message"`
+	Errors     []struct {
+		Description string `json:"description"`
+	} `json:"errors"`
+	TrackingId string `json:"trackingId"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Status
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, d := range e.Errors {
+		if d.Description != "" && d.Description != e.Message {
+			msg += "; " + d.Description
+		}
+	}
+	if e.TrackingId != "" {
+		msg += " (trackingId " + e.TrackingId + ")"
+	}
+	return msg
+}
+
+// IsStatus reports whether err is an APIError with the given status code.
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// checkStatusOk returns an APIError if res doesn't have a 2xx status.
 func checkStatusOk(res *http.Response) error {
-	if 200 < res.StatusCode && res.StatusCode > 299 {
-		// Read the body.  For some HTTP codes there's more info (e.g. 401)
-		// {
-		//	"message": "Failed to create room.",
-		//	"errors": [
-		//		{
-		//			"description": "Failed to create room."
-		//		}
-		//	],
-		//	"trackingId": "NA_f6e19aac-3a72-46d2-88ec-643f4d12fcbd"
-		//}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: res.StatusCode, Status: res.Status}
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return errors.New(res.Status + " - " + err.Error())
-		} else {
-			return errors.New(res.Status + "\n" + string(body))
+			apiErr.Message = err.Error()
+		} else if json.Unmarshal(body, apiErr) != nil {
+			// Not a JSON error response, keep whatever the body says.
+			apiErr.Message = strings.TrimSpace(string(body))
 		}
+		return apiErr
 	}
 	return nil
 }
@@ -1,24 +1,216 @@
 package util
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_checkStatusOk(t *testing.T) {
 	type args struct {
 		res *http.Response
 	}
+	response := func(code int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: code,
+			Status:     strconv.Itoa(code) + " " + http.StatusText(code),
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
 	tests := []struct {
 		name    string
 		args    args
 		wantErr bool
+		wantMsg string
 	}{
-	// TODO: Add test cases.
+		{"ok", args{response(200, "{}")}, false, ""},
+		{"no content", args{response(204, "")}, false, ""},
+		{"not found", args{response(404, `{"message": "The requested resource could not be found.", "errors": [{"description": "The requested resource could not be found."}], "trackingId": "NA_123"}`)}, true,
+			"404 Not Found: The requested resource could not be found. (trackingId NA_123)"},
+		{"descriptions", args{response(400, `{"message": "Failed to create room.", "errors": [{"description": "title is too long"}]}`)}, true,
+			"400 Bad Request: Failed to create room.; title is too long"},
+		{"not json", args{response(502, "<html>Bad Gateway</html>\n")}, true,
+			"502 Bad Gateway: <html>Bad Gateway</html>"},
 	}
 	for _, tt := range tests {
-		if err := checkStatusOk(tt.args.res); (err != nil) != tt.wantErr {
+		err := checkStatusOk(tt.args.res)
+		if (err != nil) != tt.wantErr {
 			t.Errorf("%q. checkStatusOk() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		if err.Error() != tt.wantMsg {
+			t.Errorf("%q. checkStatusOk() error = %q, want %q", tt.name, err, tt.wantMsg)
+		}
+		if !IsStatus(err, tt.args.res.StatusCode) || IsStatus(err, 200) {
+			t.Errorf("%q. IsStatus() doesn't match status %d", tt.name, tt.args.res.StatusCode)
+		}
+	}
+}
+
+func Test_contentFilename(t *testing.T) {
+	tests := []struct {
+		name        string
+		disposition string
+		urlPath     string
+		want        string
+	}{
+		{"from header", `attachment; filename="report.pdf"`, "/v1/contents/abc", "report.pdf"},
+		{"no header", "", "/v1/contents/abc", "abc"},
+		{"path in header", `attachment; filename="../../etc/passwd"`, "/v1/contents/abc", "passwd"},
+	}
+	for _, tt := range tests {
+		if got := contentFilename(tt.disposition, tt.urlPath); got != tt.want {
+			t.Errorf("%q. contentFilename() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_createUnique(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sparkcli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	want := []string{"report.pdf", "report (1).pdf", "report (2).pdf"}
+	for _, name := range want {
+		file, err := createUnique(dir, "report.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		if got := filepath.Base(file.Name()); got != name {
+			t.Errorf("createUnique() = %v, want %v", got, name)
+		}
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "5", 5 * time.Second, true},
+		{"past date", "Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+		{"garbage", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("%q. retryAfter() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOk)
 		}
 	}
 }
+
+func Test_backoff(t *testing.T) {
+	for attempt := 1; attempt < 100; attempt++ {
+		d := backoff(attempt)
+		if d <= 0 || d > retryCap {
+			t.Errorf("backoff(%d) = %v, want within (0, %v]", attempt, d, retryCap)
+		}
+	}
+}
+
+func Test_send_retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		retryAfter   string
+		wantErr      bool
+		wantRequests int
+	}{
+		{"success", "GET", []int{200}, "", false, 1},
+		{"unavailable then ok", "GET", []int{503, 502, 200}, "", false, 3},
+		{"rate limited post", "POST", []int{429, 200}, "1", false, 2},
+		{"unavailable post", "POST", []int{503, 200}, "", true, 1},
+		{"attempts used up", "DELETE", []int{504, 504, 504, 504, 200}, "", true, 3},
+		{"time used up", "GET", []int{429, 200}, "600", true, 1},
+		{"not transient", "GET", []int{500, 200}, "", true, 1},
+	}
+	for _, tt := range tests {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			if r.Method == "POST" && string(body) != `{"text":"hi"}` {
+				t.Errorf("%q. request %d has body %q", tt.name, requests, body)
+			}
+			status := tt.statuses[requests]
+			requests++
+			if status != 200 && tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte("{}"))
+		}))
+		var waited time.Duration
+		client, err := NewClient(&Configuration{BaseUrl: server.URL, RetryAttempts: 3, RetryMaxTime: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.sleep = func(ctx context.Context, d time.Duration) error {
+			waited += d
+			return nil
+		}
+
+		var body interface{}
+		if tt.method == "POST" {
+			body = map[string]string{"text": "hi"}
+		}
+		req, err := client.NewRequest(context.Background(), tt.method, "/messages", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Do(req, nil)
+		server.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q. Do() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if requests != tt.wantRequests {
+			t.Errorf("%q. server got %d requests, want %d", tt.name, requests, tt.wantRequests)
+		}
+		if tt.retryAfter == "1" && waited != time.Second {
+			t.Errorf("%q. waited %v, want Retry-After of 1s", tt.name, waited)
+		}
+	}
+}
+
+func Test_send_cancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+	client, err := NewClient(&Configuration{BaseUrl: server.URL, RetryAttempts: 3, RetryMaxTime: 120})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := client.NewGetRequest(ctx, "/rooms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = client.Do(req, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Do() returned after %v, should stop waiting to retry", elapsed)
+	}
+}
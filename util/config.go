@@ -14,12 +14,17 @@ import (
 const (
 	// redirectUrl used for OAuth flow
 	redirectUrl = "http://files.ducbase.com/code.html"
-	// scope used for OAuth flow
+	// scope used for OAuth flow, keep in sync with web/authorize.html
 	scope = "spark:people_read spark:rooms_read spark:rooms_write " +
 		"spark:messages_read spark:messages_write spark:memberships_read " +
-		"spark:memberships_write"
+		"spark:memberships_write spark:teams_read spark:teams_write " +
+		"meeting:schedules_read meeting:schedules_write"
 	// baseUrl for Cisco Spark API requests
 	baseUrl = "https://api.ciscospark.com/v1"
+	// retryAttempts is the default number of attempts per request
+	retryAttempts = 4
+	// retryMaxTime is the default time, in seconds, spent retrying a request
+	retryMaxTime = 120
 )
 
 // Configuration provides access to the config file and keeps the values
@@ -38,6 +43,31 @@ type Configuration struct {
 	RefreshToken   string
 	RefreshExpires float64
 	DefaultRoomId  string
+	// RetryAttempts caps the attempts per request, including the first; set
+	// it to 1 to disable retries.
+	RetryAttempts int
+	// RetryMaxTime caps the time, in seconds, spent on a request and its
+	// retries.
+	RetryMaxTime int
+	// Proxy is the url of the proxy for all requests.  When empty, the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are used.
+	Proxy string
+	// CABundle is a PEM file with certificates to trust besides the
+	// system ones, e.g. for a proxy that inspects TLS traffic.
+	CABundle string
+	// ClientCert and ClientKey are PEM files with a certificate and its key,
+	// for networks that require TLS client authentication.
+	ClientCert string
+	ClientKey  string
+	// InsecureSkipVerify turns off verification of server certificates.
+	// Anyone on the network can read and change requests, tokens included.
+	InsecureSkipVerify bool
+	// ConnectTimeout is how long, in seconds, to wait for a connection,
+	// including the TLS handshake.  0 means 30.
+	ConnectTimeout int
+	// ResponseTimeout is how long, in seconds, to wait for a response once
+	// a request is sent.  0 means 60.
+	ResponseTimeout int
 }
 
 var configFile string
@@ -80,6 +110,12 @@ func (c *Configuration) Load() {
 	if c.BaseUrl == "" {
 		c.BaseUrl = baseUrl
 	}
+	if c.RetryAttempts == 0 {
+		c.RetryAttempts = retryAttempts
+	}
+	if c.RetryMaxTime == 0 {
+		c.RetryMaxTime = retryMaxTime
+	}
 }
 
 // findConfigFile attempts to find the location of the config file.  It will
@@ -1,12 +1,14 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 )
 
 // Login allows authorization against the Cisco Spark service.
@@ -33,19 +35,19 @@ func NewLogin(config *Configuration, client *Client) Login {
 // it will attempt to use the OAuth integration flow. to obtain an access token
 // based on the provided ClientId, ClientSecret and AuthCode in the
 // configuration.
-func (l Login) Authorize() {
+func (l Login) Authorize(ctx context.Context) {
 	// Check if AccessToken is present
 	tokenPresent := l.config.checkAccessToken()
 	if tokenPresent {
 		// Verify if token works.
-		err := l.test()
+		err := l.test(ctx)
 		if err != nil {
-			l.loginAsIntegration()
+			l.loginAsIntegration(ctx)
 		} else { // Success!
 			return
 		}
 	} else { // AccessToken not present
-		l.loginAsIntegration()
+		l.loginAsIntegration(ctx)
 	}
 }
 
@@ -55,7 +57,7 @@ func (l Login) Authorize() {
 // On successful authentication it will store the AccessToken and RefreshToken
 // in the configuration file for further use.  On failure it will exit the
 // program.
-func (l Login) loginAsIntegration() {
+func (l Login) loginAsIntegration(ctx context.Context) {
 	// Check if client credentials are set.
 	err := l.config.checkClientConfig()
 	if err != nil { // If client credentials are not set...
@@ -65,7 +67,7 @@ func (l Login) loginAsIntegration() {
 
 	log.Println("Authorizing...")
 	// Post form to obtain access token based on authorization code (OAuth)
-	res, err := http.PostForm(l.config.BaseUrl+"/access_token",
+	res, err := l.postForm(ctx, "/access_token",
 		url.Values{"grant_type": {"authorization_code"},
 			"client_id":     {l.config.ClientId},
 			"client_secret": {l.config.ClientSecret},
@@ -79,7 +81,7 @@ func (l Login) loginAsIntegration() {
 	// if 401, reauthorize? or refresh key.
 	if res.StatusCode == 401 {
 		log.Print("Unauthorized (401) - trying to refresh token")
-		l.RefreshToken()
+		l.RefreshToken(ctx)
 	} else if res.StatusCode != 200 {
 		log.Fatal("Unexpected status code ", res.StatusCode)
 	}
@@ -103,10 +105,10 @@ func (l Login) loginAsIntegration() {
 // On success, the new AccessToken is written into the configuration
 // file.  The RefreshToken remains the same, its expiry is reset.
 // Note that sparkcli doesn't track token expiry.
-func (l Login) RefreshToken() {
+func (l Login) RefreshToken(ctx context.Context) {
 	log.Print("Refreshing token...")
 	// Post form to obtain access token based on refresh token (OAuth)
-	res, err := http.PostForm(l.config.BaseUrl+"/access_token",
+	res, err := l.postForm(ctx, "/access_token",
 		url.Values{"grant_type": {"refresh_token"},
 			"client_id":     {l.config.ClientId},
 			"client_secret": {l.config.ClientSecret},
@@ -138,6 +140,17 @@ func (l Login) RefreshToken() {
 	log.Printf("Successfully refreshed token.")
 }
 
+// postForm posts values as a form to path, relative to the base url.
+func (l Login) postForm(ctx context.Context, path string, values url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", l.config.BaseUrl+path,
+		strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return l.client.client.Do(req)
+}
+
 // storeToken writes tokens to the configuration file.  When refresh
 // is true, it will not overwrite RefreshToken and RefreshExpires (since
 // these will be empty during refresh)
@@ -161,8 +174,8 @@ func (l Login) storeToken(tokens *Tokens, refresh bool) {
 
 // test access to the Cisco Spark service to ensure authentication works as
 // expected.  Returns an error if the service request fails.
-func (l Login) test() error {
-	req, err := l.client.NewGetRequest("/people/me")
+func (l Login) test(ctx context.Context) error {
+	req, err := l.client.NewGetRequest(ctx, "/people/me")
 	if err != nil {
 		log.Fatalf("Error testing connection: %s", err)
 	}
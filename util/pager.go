@@ -0,0 +1,140 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Paging says how far a Pager follows the pages of a list.  The zero value
+// only retrieves the first page, like a plain request.
+type Paging struct {
+	All   bool // retrieve all pages
+	Limit int  // retrieve pages until there are this many items, 0 for no limit
+}
+
+// Pager retrieves the pages of a list request one at a time.  The service
+// returns the url of the next page in an RFC 5988 Link header:
+//
+//	Link: <https://api.ciscospark.com/v1/rooms?cursor=...>; rel="next"
+type Pager struct {
+	client *Client
+	req    *http.Request // request for the next page, nil when done
+	paging Paging
+	count  int // items retrieved so far
+}
+
+// NewPager creates a Pager that starts with req.
+func (c *Client) NewPager(req *http.Request, paging Paging) *Pager {
+	return &Pager{client: c, req: req, paging: paging}
+}
+
+// More reports whether there's another page to retrieve.
+func (p *Pager) More() bool {
+	return p.req != nil
+}
+
+// NextRaw retrieves the next page and returns its items undecoded.
+func (p *Pager) NextRaw() ([]json.RawMessage, error) {
+	if p.req == nil {
+		return nil, errors.New("No more pages")
+	}
+	var page struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	req := p.req
+	res, err := p.client.Do(req, &page)
+	p.req = nil
+	if err != nil {
+		return nil, err
+	}
+	items := page.Items
+	if p.paging.Limit > 0 && p.count+len(items) > p.paging.Limit {
+		items = items[:p.paging.Limit-p.count]
+	}
+	p.count += len(items)
+	if p.paging.All || (p.paging.Limit > 0 && p.count < p.paging.Limit) {
+		if next := nextLink(res.Header.Get("Link")); next != "" {
+			p.req, err = p.client.NewContentRequest(req.Context(), next)
+			if err != nil {
+				return items, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// Next retrieves the next page and decodes its items into items, which must
+// be a pointer to a slice (e.g. *[]api.Room).  The items of earlier pages are
+// replaced, not merged.
+func (p *Pager) Next(items interface{}) error {
+	raw, err := p.NextRaw()
+	if err != nil {
+		return err
+	}
+	return decodeItems(raw, items)
+}
+
+// Collect retrieves all remaining pages and decodes their items into items,
+// which must be a pointer to a slice.
+func (p *Pager) Collect(items interface{}) error {
+	var all []json.RawMessage
+	for p.More() {
+		raw, err := p.NextRaw()
+		if err != nil {
+			return err
+		}
+		all = append(all, raw...)
+	}
+	return decodeItems(all, items)
+}
+
+// decodeItems decodes raw into the slice items points to.  The slice is
+// emptied first; json.Unmarshal would otherwise reuse its elements and keep
+// fields that are missing from the new items.
+func decodeItems(raw []json.RawMessage, items interface{}) error {
+	if raw == nil {
+		raw = []json.RawMessage{}
+	}
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, items)
+}
+
+// nextLink returns the url with rel="next" from an RFC 5988 Link header, or
+// an empty string if there is none.  The urls may contain commas (e.g. a list
+// of ids), so the header is split on the angle brackets.
+func nextLink(header string) string {
+	for {
+		start := strings.Index(header, "<")
+		end := strings.Index(header, ">")
+		if start < 0 || end < start {
+			return ""
+		}
+		link := header[start+1 : end]
+		header = header[end+1:]
+		params := header
+		if i := strings.Index(header, "<"); i >= 0 {
+			params = header[:i]
+		}
+		for _, param := range strings.Split(params, ";") {
+			param = strings.Trim(strings.TrimSpace(param), ",")
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(kv[1]), `"`)) {
+				if rel == "next" {
+					return link
+				}
+			}
+		}
+	}
+}
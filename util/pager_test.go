@@ -0,0 +1,131 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func Test_nextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"next", `<https://api/v1/rooms?cursor=abc>; rel="next"`, "https://api/v1/rooms?cursor=abc"},
+		{"unquoted", `<https://api/v1/rooms?cursor=abc>; rel=next`, "https://api/v1/rooms?cursor=abc"},
+		{"prev only", `<https://api/v1/rooms?cursor=abc>; rel="prev"`, ""},
+		{"several", `<https://api/v1/a>; rel="prev", <https://api/v1/b>; rel="next"`, "https://api/v1/b"},
+		{"comma in url", `<https://api/v1/people?id=a,b,c>; rel="next"`, "https://api/v1/people?id=a,b,c"},
+	}
+	for _, tt := range tests {
+		if got := nextLink(tt.header); got != tt.want {
+			t.Errorf("%q. nextLink() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// pagedServer serves 3 pages of 2 items each, linked with Link headers.
+func pagedServer() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=%d>; rel="next"`, server.URL, page+1))
+		}
+		fmt.Fprintf(w, `{"items": [{"id": "%d"}, {"id": "%d"}]}`, page*2, page*2+1)
+	}))
+	return server
+}
+
+func Test_Pager(t *testing.T) {
+	server := pagedServer()
+	defer server.Close()
+	client, err := NewClient(&Configuration{BaseUrl: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		paging Paging
+		want   int
+	}{
+		{"first page", Paging{}, 2},
+		{"all", Paging{All: true}, 6},
+		{"limit within page", Paging{Limit: 1}, 1},
+		{"limit over pages", Paging{Limit: 5}, 5},
+		{"limit beyond all", Paging{Limit: 10}, 6},
+	}
+	for _, tt := range tests {
+		req, err := client.NewGetRequest(context.Background(), "/items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var items []struct {
+			Id string `json:"id"`
+		}
+		err = client.NewPager(req, tt.paging).Collect(&items)
+		if err != nil {
+			t.Errorf("%q. Collect() error = %v", tt.name, err)
+			continue
+		}
+		if len(items) != tt.want {
+			t.Errorf("%q. Collect() got %d items, want %d", tt.name, len(items), tt.want)
+			continue
+		}
+		for i, item := range items {
+			if item.Id != strconv.Itoa(i) {
+				t.Errorf("%q. item %d has id %s", tt.name, i, item.Id)
+			}
+		}
+	}
+}
+
+func Test_Pager_Next(t *testing.T) {
+	// The second page leaves out teamId, which the first page set.
+	pages := []string{
+		`{"items": [{"id": "a", "teamId": "T1"}, {"id": "b", "teamId": "T2"}]}`,
+		`{"items": [{"id": "c"}, {"id": "d"}]}`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/rooms?page=%d>; rel="next"`, server.URL, page+1))
+		}
+		fmt.Fprint(w, pages[page])
+	}))
+	defer server.Close()
+	client, err := NewClient(&Configuration{BaseUrl: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := client.NewGetRequest(context.Background(), "/rooms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pager := client.NewPager(req, Paging{All: true})
+	var rooms []struct {
+		Id     string `json:"id"`
+		TeamId string `json:"teamId"`
+	}
+	want := [][2]string{{"a", "T1"}, {"b", "T2"}, {"c", ""}, {"d", ""}}
+	var got [][2]string
+	for pager.More() {
+		if err := pager.Next(&rooms); err != nil {
+			t.Fatal(err)
+		}
+		for _, room := range rooms {
+			got = append(got, [2]string{room.Id, room.TeamId})
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Next() got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// connectTimeout is the default for Configuration.ConnectTimeout.
+	connectTimeout = 30 * time.Second
+	// responseTimeout is the default for Configuration.ResponseTimeout.
+	responseTimeout = 60 * time.Second
+)
+
+// newTransport builds the transport for requests from the proxy, TLS and
+// timeout settings of config.  Returns an error when a setting is invalid,
+// e.g. a CABundle that doesn't exist.
+func newTransport(config *Configuration) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	connect := seconds(config.ConnectTimeout, connectTimeout)
+	dialer := &net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = connect
+	transport.ResponseHeaderTimeout = seconds(config.ResponseTimeout, responseTimeout)
+
+	if config.Proxy != "" {
+		proxy := config.Proxy
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		proxyUrl, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("Proxy %s: %s", config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.CABundle != "" {
+		pem, err := ioutil.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("CABundle: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CABundle: no certificates found in " + config.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ClientCert != "" || config.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("ClientCert and ClientKey: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if config.InsecureSkipVerify {
+		log.Println("WARNING: InsecureSkipVerify is set, server certificates are NOT " +
+			"verified.  Anyone on the network can read and change requests, " +
+			"including your access token.  Use CABundle instead.")
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// seconds returns value as a duration in seconds, or def if value isn't set.
+func seconds(value int, def time.Duration) time.Duration {
+	if value <= 0 {
+		return def
+	}
+	return time.Duration(value) * time.Second
+}
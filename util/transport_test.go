@@ -0,0 +1,165 @@
+package util
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block of type typ with der to a file in dir, and
+// returns its path.
+func writePEM(t *testing.T, dir string, name string, typ string, der []byte) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCert creates a self-signed client certificate and writes it and its
+// key to dir.  Returns the certificate and the paths of both files.
+func clientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sparkcli"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, writePEM(t, dir, "client.pem", "CERTIFICATE", der),
+		writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDer)
+}
+
+// get sends a GET for /people/me with a Client for config.
+func get(t *testing.T, config *Configuration) error {
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := client.NewGetRequest(context.Background(), "/people/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Do(req, nil)
+	return err
+}
+
+func Test_newTransport_TLS(t *testing.T) {
+	dir := t.TempDir()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	server := httptest.NewTLSServer(ok)
+	defer server.Close()
+	caBundle := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	cert, certFile, keyFile := clientCert(t, dir)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	mtlsServer := httptest.NewUnstartedServer(ok)
+	mtlsServer.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	mtlsServer.StartTLS()
+	defer mtlsServer.Close()
+	mtlsBundle := writePEM(t, dir, "mtls-ca.pem", "CERTIFICATE", mtlsServer.Certificate().Raw)
+
+	tests := []struct {
+		name    string
+		config  Configuration
+		wantErr bool
+	}{
+		{"unknown CA", Configuration{BaseUrl: server.URL}, true},
+		{"CA bundle", Configuration{BaseUrl: server.URL, CABundle: caBundle}, false},
+		{"insecure", Configuration{BaseUrl: server.URL, InsecureSkipVerify: true}, false},
+		{"no client cert", Configuration{BaseUrl: mtlsServer.URL, CABundle: mtlsBundle}, true},
+		{"client cert", Configuration{BaseUrl: mtlsServer.URL, CABundle: mtlsBundle,
+			ClientCert: certFile, ClientKey: keyFile}, false},
+	}
+	for _, tt := range tests {
+		if err := get(t, &tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("%q. request error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func Test_newTransport_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte("{}"))
+	}))
+	defer proxy.Close()
+
+	config := Configuration{BaseUrl: "http://api.sparkcli.invalid/v1", Proxy: proxy.URL}
+	if err := get(t, &config); err != nil {
+		t.Fatalf("request error = %v", err)
+	}
+	if want := "http://api.sparkcli.invalid/v1/people/me"; proxied != want {
+		t.Errorf("proxy got request for %q, want %q", proxied, want)
+	}
+}
+
+func Test_newTransport_invalid(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := ioutil.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		config Configuration
+	}{
+		{"missing CA bundle", Configuration{CABundle: filepath.Join(dir, "missing.pem")}},
+		{"empty CA bundle", Configuration{CABundle: empty}},
+		{"cert without key", Configuration{ClientCert: empty}},
+		{"bad proxy", Configuration{Proxy: "http://proxy:port"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewClient(&tt.config); err == nil {
+			t.Errorf("%q. NewClient() succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func Test_newTransport_timeouts(t *testing.T) {
+	transport, err := newTransport(&Configuration{ConnectTimeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.TLSHandshakeTimeout != 5*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %v, want 5s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != responseTimeout {
+		t.Errorf("ResponseHeaderTimeout = %v, want default %v", transport.ResponseHeaderTimeout, responseTimeout)
+	}
+}
@@ -2,10 +2,22 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
 )
 
+// Debug enables the log lines written by Debugf.
+var Debug bool
+
+// Debugf logs like log.Printf, but only when Debug is set.
+func Debugf(format string, v ...interface{}) {
+	if Debug {
+		log.Printf("DEBUG: "+format, v...)
+	}
+}
+
 // PrintJson prints v with proper indents
 func PrintJson(v interface{}) error {
 	jsonMsg, err := json.MarshalIndent(v, "", "  ")
@@ -15,3 +27,44 @@ func PrintJson(v interface{}) error {
 	fmt.Print(string(jsonMsg))
 	return nil
 }
+
+// PrintJsonLine prints v as compact JSON on a single line, so a list of
+// values can be streamed as JSON lines.
+func PrintJsonLine(v interface{}) error {
+	jsonMsg, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonMsg))
+	return nil
+}
+
+// PrintJsonPages prints the items of all pages of pager as one JSON array,
+// formatted like PrintJson.  Items are printed as soon as their page
+// arrives.
+func PrintJsonPages(pager *Pager) error {
+	fmt.Print("[")
+	count := 0
+	for pager.More() {
+		items, err := pager.NextRaw()
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, item, "  ", "  "); err != nil {
+				return err
+			}
+			if count > 0 {
+				fmt.Print(",")
+			}
+			fmt.Print("\n  " + buf.String())
+			count++
+		}
+	}
+	if count > 0 {
+		fmt.Print("\n")
+	}
+	fmt.Print("]")
+	return nil
+}